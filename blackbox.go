@@ -0,0 +1,590 @@
+package phase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// ParameterSet picks the parameters of a Phase that a black-box optimizer searches. Parameters
+// returns pointers into bp in a fixed order, so the same set gives matching vectors for every
+// copy of a model.
+type ParameterSet interface {
+	Parameters(bp *Phase) []*float64
+}
+
+// NeuronParameters selects the parameters of the listed neurons, each laid out like
+// GetNewNeuronParameters: incoming weights, bias, weights into the outputs, then the
+// type-specific parameters.
+type NeuronParameters []int
+
+// Parameters implements ParameterSet.
+func (ids NeuronParameters) Parameters(bp *Phase) []*float64 {
+	params := []*float64{}
+	for _, id := range ids {
+		neuron, exists := bp.Neurons[id]
+		if !exists {
+			continue
+		}
+		for i := range neuron.Connections {
+			params = append(params, &neuron.Connections[i].Weight)
+		}
+		params = append(params, &neuron.Bias)
+		for _, outID := range bp.OutputNodes {
+			outNeuron := bp.Neurons[outID]
+			for i, conn := range outNeuron.Connections {
+				if conn.Source == id {
+					params = append(params, &outNeuron.Connections[i].Weight)
+					break
+				}
+			}
+		}
+		params = append(params, typeParameters(neuron)...)
+	}
+	return params
+}
+
+// NetworkParameters selects every trainable parameter: for each non-input neuron in ID order,
+// its incoming weights, its bias and its type-specific parameters.
+type NetworkParameters struct{}
+
+// Parameters implements ParameterSet.
+func (NetworkParameters) Parameters(bp *Phase) []*float64 {
+	params := []*float64{}
+	for _, id := range bp.sortedNeuronIDs() {
+		neuron := bp.Neurons[id]
+		if neuron.Type == "input" {
+			continue
+		}
+		for i := range neuron.Connections {
+			params = append(params, &neuron.Connections[i].Weight)
+		}
+		params = append(params, &neuron.Bias)
+		params = append(params, typeParameters(neuron)...)
+	}
+	return params
+}
+
+// BatchObjective scores a batch of candidate vectors; higher is better. Candidates of one batch
+// are independent, so an objective may evaluate them in parallel.
+type BatchObjective func(candidates [][]float64) []float64
+
+// BlackBoxOptimizer maximizes an objective from a starting point x0, drawing from rng, until
+// its own budget is spent or ctx is done. It returns its final estimate of the optimum. The
+// caller also keeps the best candidate the objective has seen, so an optimizer need not.
+type BlackBoxOptimizer interface {
+	Optimize(ctx context.Context, objective BatchObjective, x0 []float64, rng *rand.Rand) []float64
+}
+
+// BlackBoxConfig configures OptimizeBlackBox.
+type BlackBoxConfig struct {
+	Optimizer   BlackBoxOptimizer // Defaults to CMAES{}
+	Parameters  ParameterSet      // Defaults to NetworkParameters{}
+	Checkpoints []map[int]map[string]interface{}
+	Labels      []float64
+	Workers     int               // Candidates evaluated at once; defaults to 80% of the cores
+	Rand        *rand.Rand        // Source of randomness; nil uses bp's
+	Policy      ImprovementPolicy // Scores candidates; nil uses bp's ImprovementPolicy
+}
+
+// BlackBoxResult is the outcome of OptimizeBlackBox. Its ModelResult holds bp and its metrics
+// after optimization.
+type BlackBoxResult struct {
+	ModelResult
+	Parameters  []float64 // Final values of the selected parameters
+	Improvement float64   // Improvement over the starting metrics under the config's policy
+	Evaluations int       // Candidates evaluated
+}
+
+// OptimizeBlackBox searches the parameters selected by cfg.Parameters with cfg.Optimizer,
+// scoring each candidate by its improvement over bp's starting metrics on the checkpoints.
+// Candidates of a batch are evaluated in parallel, each worker on its own copy of bp. The best
+// candidate is written back to bp only if it improves on the start, so bp never gets worse. It
+// returns ctx.Err() if the search was cut short.
+func (bp *Phase) OptimizeBlackBox(ctx context.Context, cfg BlackBoxConfig) (BlackBoxResult, error) {
+	if cfg.Optimizer == nil {
+		cfg.Optimizer = CMAES{}
+	}
+	if cfg.Parameters == nil {
+		cfg.Parameters = NetworkParameters{}
+	}
+	if cfg.Workers < 1 {
+		cfg.Workers = multiCoreWorkers()
+	}
+	if cfg.Rand == nil {
+		cfg.Rand = bp.rng()
+	}
+	if len(cfg.Checkpoints) == 0 || len(cfg.Labels) != len(cfg.Checkpoints) {
+		return BlackBoxResult{}, fmt.Errorf("blackbox: need one label per checkpoint, got %d checkpoints and %d labels", len(cfg.Checkpoints), len(cfg.Labels))
+	}
+	params := cfg.Parameters.Parameters(bp)
+	if len(params) == 0 {
+		return BlackBoxResult{}, fmt.Errorf("blackbox: the parameter set selects no parameters")
+	}
+	x0 := make([]float64, len(params))
+	for i, p := range params {
+		x0[i] = *p
+	}
+
+	eval := newCheckpointEvaluator(bp, cfg)
+	start := eval.metrics([][]float64{x0})[0]
+	policy := bp.resolvePolicy(cfg.Policy)
+	startCloseness := policy.ClosenessQuality(start.ClosenessBins)
+	best, bestX, bestImprovement := start, x0, 0.0
+
+	objective := func(candidates [][]float64) []float64 {
+		results := eval.metrics(candidates)
+		scores := make([]float64, len(results))
+		for i, result := range results {
+			scores[i] = policy.Improvement(result, start.ExactAcc, startCloseness, start.ApproxScore)
+			if scores[i] > bestImprovement {
+				best, bestX, bestImprovement = result, append([]float64(nil), candidates[i]...), scores[i]
+			}
+		}
+		return scores
+	}
+	final := cfg.Optimizer.Optimize(ctx, objective, append([]float64(nil), x0...), cfg.Rand)
+	if len(final) == len(x0) && ctx.Err() == nil {
+		objective([][]float64{final})
+	}
+
+	for i, p := range params {
+		*p = bestX[i]
+	}
+	if bp.Debug {
+		fmt.Printf("Black-box optimization of %d parameters: %d evaluations, improvement %.4f\n", len(x0), eval.evaluations, bestImprovement)
+	}
+	best.BP = bp
+	return BlackBoxResult{ModelResult: best, Parameters: bestX, Improvement: bestImprovement, Evaluations: eval.evaluations - 1}, ctx.Err()
+}
+
+// checkpointEvaluator scores parameter vectors with EvaluateMetricsFromCheckpoints on
+// per-worker copies of a model.
+type checkpointEvaluator struct {
+	workers     []*Phase
+	params      [][]*float64 // params[w] selects the parameters of workers[w]
+	checkpoints []map[int]map[string]interface{}
+	labels      []float64
+	evaluations int
+}
+
+func newCheckpointEvaluator(bp *Phase, cfg BlackBoxConfig) *checkpointEvaluator {
+	e := &checkpointEvaluator{checkpoints: cfg.Checkpoints, labels: cfg.Labels}
+	for w := 0; w < cfg.Workers; w++ {
+		worker := bp.workerCopy(w)
+		e.workers = append(e.workers, worker)
+		e.params = append(e.params, cfg.Parameters.Parameters(worker))
+	}
+	return e
+}
+
+// metrics evaluates the candidates in parallel and returns their metrics in order.
+func (e *checkpointEvaluator) metrics(candidates [][]float64) []ModelResult {
+	results := make([]ModelResult, len(candidates))
+	jobs := make(chan int, len(candidates))
+	for i := range candidates {
+		jobs <- i
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(len(e.workers), len(candidates)); w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := range jobs {
+				for j, p := range e.params[w] {
+					*p = candidates[i][j]
+				}
+				exactAcc, closenessBins, approxScore := e.workers[w].EvaluateMetricsFromCheckpoints(e.checkpoints, e.labels)
+				results[i] = ModelResult{ExactAcc: exactAcc, ClosenessBins: closenessBins, ApproxScore: approxScore}
+			}
+		}(w)
+	}
+	wg.Wait()
+	e.evaluations += len(candidates)
+	return results
+}
+
+// CMAES is the covariance matrix adaptation evolution strategy (Hansen's (mu/mu_w, lambda)
+// CMA-ES). It learns a full covariance matrix, so it suits parameter sets of up to a few
+// hundred values, such as the parameters of a few new neurons.
+type CMAES struct {
+	Sigma          float64 // Initial step size; defaults to 0.1
+	PopulationSize int     // Candidates per generation (lambda); defaults to 4 + 3 ln(n)
+	Generations    int     // Defaults to 50
+	Tolerance      float64 // Stops once sigma times the largest axis falls below it; defaults to 1e-8
+}
+
+// Optimize implements BlackBoxOptimizer.
+func (c CMAES) Optimize(ctx context.Context, objective BatchObjective, x0 []float64, rng *rand.Rand) []float64 {
+	n := len(x0)
+	nf := float64(n)
+	sigma := c.Sigma
+	if sigma <= 0 {
+		sigma = 0.1
+	}
+	lambda := c.PopulationSize
+	if lambda < 2 {
+		lambda = 4 + int(3*math.Log(nf))
+	}
+	generations := c.Generations
+	if generations < 1 {
+		generations = 50
+	}
+	tolerance := c.Tolerance
+	if tolerance <= 0 {
+		tolerance = 1e-8
+	}
+
+	// Recombination weights and strategy constants from Hansen's tutorial.
+	mu := lambda / 2
+	weights := make([]float64, mu)
+	sum, sumSq := 0.0, 0.0
+	for i := range weights {
+		weights[i] = math.Log(float64(mu)+0.5) - math.Log(float64(i+1))
+		sum += weights[i]
+	}
+	for i := range weights {
+		weights[i] /= sum
+		sumSq += weights[i] * weights[i]
+	}
+	mueff := 1 / sumSq
+	cc := (4 + mueff/nf) / (nf + 4 + 2*mueff/nf)
+	cs := (mueff + 2) / (nf + mueff + 5)
+	c1 := 2 / ((nf+1.3)*(nf+1.3) + mueff)
+	cmu := math.Min(1-c1, 2*(mueff-2+1/mueff)/((nf+2)*(nf+2)+mueff))
+	damps := 1 + 2*math.Max(0, math.Sqrt((mueff-1)/(nf+1))-1) + cs
+	chiN := math.Sqrt(nf) * (1 - 1/(4*nf) + 1/(21*nf*nf))
+
+	mean := x0
+	pc := make([]float64, n)
+	ps := make([]float64, n)
+	C := identityMatrix(n)
+	B := identityMatrix(n)
+	D := make([]float64, n)
+	for i := range D {
+		D[i] = 1
+	}
+	evaluations, eigenEvaluations := 0, 0
+
+	for gen := 0; gen < generations && ctx.Err() == nil; gen++ {
+		ys := make([][]float64, lambda)
+		xs := make([][]float64, lambda)
+		for k := range xs {
+			z := make([]float64, n)
+			for i := range z {
+				z[i] = D[i] * rng.NormFloat64()
+			}
+			ys[k] = matVec(B, z)
+			xs[k] = make([]float64, n)
+			for i := range xs[k] {
+				xs[k][i] = mean[i] + sigma*ys[k][i]
+			}
+		}
+		fitness := objective(xs)
+		evaluations += lambda
+		order := make([]int, lambda)
+		for k := range order {
+			order[k] = k
+		}
+		sort.SliceStable(order, func(a, b int) bool { return fitness[order[a]] > fitness[order[b]] })
+
+		// Move the mean to the weighted average of the best mu steps.
+		yw := make([]float64, n)
+		for k, w := range weights {
+			for i := range yw {
+				yw[i] += w * ys[order[k]][i]
+			}
+		}
+		for i := range mean {
+			mean[i] += sigma * yw[i]
+		}
+
+		// Evolution paths; ps uses C^(-1/2) yw = B D^-1 B^T yw.
+		bty := make([]float64, n)
+		for j := 0; j < n; j++ {
+			for i := 0; i < n; i++ {
+				bty[j] += B[i][j] * yw[i]
+			}
+			bty[j] /= D[j]
+		}
+		invSqrtY := matVec(B, bty)
+		psNorm := 0.0
+		for i := range ps {
+			ps[i] = (1-cs)*ps[i] + math.Sqrt(cs*(2-cs)*mueff)*invSqrtY[i]
+			psNorm += ps[i] * ps[i]
+		}
+		psNorm = math.Sqrt(psNorm)
+		hsig := 0.0
+		if psNorm/math.Sqrt(1-math.Pow(1-cs, 2*float64(gen+1)))/chiN < 1.4+2/(nf+1) {
+			hsig = 1
+		}
+		for i := range pc {
+			pc[i] = (1-cc)*pc[i] + hsig*math.Sqrt(cc*(2-cc)*mueff)*yw[i]
+		}
+
+		// Rank-one and rank-mu covariance update.
+		for i := 0; i < n; i++ {
+			for j := 0; j <= i; j++ {
+				rankMu := 0.0
+				for k, w := range weights {
+					rankMu += w * ys[order[k]][i] * ys[order[k]][j]
+				}
+				v := (1-c1-cmu)*C[i][j] + c1*(pc[i]*pc[j]+(1-hsig)*cc*(2-cc)*C[i][j]) + cmu*rankMu
+				C[i][j], C[j][i] = v, v
+			}
+		}
+		sigma *= math.Exp((cs / damps) * (psNorm/chiN - 1))
+
+		// Decompose C lazily; it changes slowly relative to the sampling.
+		if float64(evaluations-eigenEvaluations) > float64(lambda)/((c1+cmu)*nf*10) {
+			eigenEvaluations = evaluations
+			values, vectors := symmetricEigen(C)
+			B = vectors
+			for i, v := range values {
+				D[i] = math.Sqrt(math.Max(v, 1e-20))
+			}
+		}
+		maxD := 0.0
+		for _, d := range D {
+			maxD = math.Max(maxD, d)
+		}
+		if sigma*maxD < tolerance {
+			break
+		}
+	}
+	return mean
+}
+
+// NES is the natural evolution strategy of Salimans et al. (OpenAI ES). Each generation
+// samples Pairs antithetic perturbations theta+sigma*eps and theta-sigma*eps, replaces their
+// scores with centered ranks, and steps along the resulting gradient estimate. Rank shaping
+// makes it indifferent to the scale of the objective.
+type NES struct {
+	Sigma        float64 // Perturbation size; defaults to 0.05
+	LearningRate float64 // Defaults to 0.02
+	Pairs        int     // Antithetic pairs per generation; defaults to 8
+	Generations  int     // Defaults to 50
+}
+
+// Optimize implements BlackBoxOptimizer.
+func (e NES) Optimize(ctx context.Context, objective BatchObjective, x0 []float64, rng *rand.Rand) []float64 {
+	sigma := e.Sigma
+	if sigma <= 0 {
+		sigma = 0.05
+	}
+	learningRate := e.LearningRate
+	if learningRate <= 0 {
+		learningRate = 0.02
+	}
+	pairs := e.Pairs
+	if pairs < 1 {
+		pairs = 8
+	}
+	generations := e.Generations
+	if generations < 1 {
+		generations = 50
+	}
+
+	theta := x0
+	for gen := 0; gen < generations && ctx.Err() == nil; gen++ {
+		eps := make([][]float64, pairs)
+		candidates := make([][]float64, 0, 2*pairs)
+		for k := range eps {
+			eps[k] = make([]float64, len(theta))
+			plus := make([]float64, len(theta))
+			minus := make([]float64, len(theta))
+			for i := range theta {
+				eps[k][i] = rng.NormFloat64()
+				plus[i] = theta[i] + sigma*eps[k][i]
+				minus[i] = theta[i] - sigma*eps[k][i]
+			}
+			candidates = append(candidates, plus, minus)
+		}
+		shaped := centeredRanks(objective(candidates))
+		for i := range theta {
+			grad := 0.0
+			for k := range eps {
+				grad += (shaped[2*k] - shaped[2*k+1]) * eps[k][i]
+			}
+			theta[i] += learningRate * grad / (float64(2*pairs) * sigma)
+		}
+	}
+	return theta
+}
+
+// centeredRanks maps scores to their ranks scaled to [-0.5, 0.5]. Tied scores share their
+// average rank, so a flat objective yields no gradient.
+func centeredRanks(scores []float64) []float64 {
+	n := len(scores)
+	ranks := make([]float64, n)
+	if n < 2 {
+		return ranks
+	}
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] < scores[order[b]] })
+	for start := 0; start < n; {
+		end := start + 1
+		for end < n && scores[order[end]] == scores[order[start]] {
+			end++
+		}
+		rank := float64(start+end-1)/2/float64(n-1) - 0.5
+		for _, i := range order[start:end] {
+			ranks[i] = rank
+		}
+		start = end
+	}
+	return ranks
+}
+
+// SPSA is simultaneous perturbation stochastic approximation (Spall). Each iteration perturbs
+// every parameter at once by +-c_k and estimates the gradient from the two scores, so its cost
+// per step does not grow with the number of parameters. The gains follow Spall's schedules
+// a_k = StepSize/(k+1+Stability)^Alpha and c_k = Perturbation/(k+1)^Gamma.
+type SPSA struct {
+	StepSize     float64 // a; defaults to 0.1
+	Perturbation float64 // c; defaults to 0.1
+	Stability    float64 // A; defaults to a tenth of Iterations
+	Alpha        float64 // Defaults to 0.602
+	Gamma        float64 // Defaults to 0.101
+	Gradients    int     // Gradient estimates averaged per iteration, evaluated in parallel; defaults to 1
+	Iterations   int     // Defaults to 100
+}
+
+// Optimize implements BlackBoxOptimizer.
+func (s SPSA) Optimize(ctx context.Context, objective BatchObjective, x0 []float64, rng *rand.Rand) []float64 {
+	if s.StepSize <= 0 {
+		s.StepSize = 0.1
+	}
+	if s.Perturbation <= 0 {
+		s.Perturbation = 0.1
+	}
+	if s.Iterations < 1 {
+		s.Iterations = 100
+	}
+	if s.Stability <= 0 {
+		s.Stability = float64(s.Iterations) / 10
+	}
+	if s.Alpha <= 0 {
+		s.Alpha = 0.602
+	}
+	if s.Gamma <= 0 {
+		s.Gamma = 0.101
+	}
+	if s.Gradients < 1 {
+		s.Gradients = 1
+	}
+
+	theta := x0
+	for k := 0; k < s.Iterations && ctx.Err() == nil; k++ {
+		ak := s.StepSize / math.Pow(float64(k+1)+s.Stability, s.Alpha)
+		ck := s.Perturbation / math.Pow(float64(k+1), s.Gamma)
+
+		deltas := make([][]float64, s.Gradients)
+		candidates := make([][]float64, 0, 2*s.Gradients)
+		for g := range deltas {
+			deltas[g] = make([]float64, len(theta))
+			plus := make([]float64, len(theta))
+			minus := make([]float64, len(theta))
+			for i := range theta {
+				deltas[g][i] = 1
+				if rng.Intn(2) == 0 {
+					deltas[g][i] = -1
+				}
+				plus[i] = theta[i] + ck*deltas[g][i]
+				minus[i] = theta[i] - ck*deltas[g][i]
+			}
+			candidates = append(candidates, plus, minus)
+		}
+		scores := objective(candidates)
+		for i := range theta {
+			grad := 0.0
+			for g, delta := range deltas {
+				grad += (scores[2*g] - scores[2*g+1]) / (2 * ck * delta[i])
+			}
+			theta[i] += ak * grad / float64(s.Gradients)
+		}
+	}
+	return theta
+}
+
+// identityMatrix returns the n x n identity matrix.
+func identityMatrix(n int) [][]float64 {
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n)
+		m[i][i] = 1
+	}
+	return m
+}
+
+// matVec returns m times v.
+func matVec(m [][]float64, v []float64) []float64 {
+	out := make([]float64, len(m))
+	for i, row := range m {
+		for j, x := range row {
+			out[i] += x * v[j]
+		}
+	}
+	return out
+}
+
+// symmetricEigen diagonalizes the symmetric matrix a with cyclic Jacobi rotations. It returns
+// the eigenvalues and a matrix whose columns are the matching eigenvectors; a is not changed.
+func symmetricEigen(a [][]float64) ([]float64, [][]float64) {
+	n := len(a)
+	m := make([][]float64, n)
+	for i := range a {
+		m[i] = append([]float64(nil), a[i]...)
+	}
+	v := identityMatrix(n)
+	for sweep := 0; sweep < 50; sweep++ {
+		off := 0.0
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				off += m[i][j] * m[i][j]
+			}
+		}
+		if off < 1e-22 {
+			break
+		}
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if math.Abs(m[p][q]) < 1e-300 {
+					continue
+				}
+				theta := (m[q][q] - m[p][p]) / (2 * m[p][q])
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < n; k++ {
+					mkp, mkq := m[k][p], m[k][q]
+					m[k][p], m[k][q] = c*mkp-s*mkq, s*mkp+c*mkq
+				}
+				for k := 0; k < n; k++ {
+					mpk, mqk := m[p][k], m[q][k]
+					m[p][k], m[q][k] = c*mpk-s*mqk, s*mpk+c*mqk
+				}
+				for k := 0; k < n; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p], v[k][q] = c*vkp-s*vkq, s*vkp+c*vkq
+				}
+			}
+		}
+	}
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = m[i][i]
+	}
+	return values, v
+}
@@ -15,6 +15,18 @@ type Phase struct {
 	ScalarActivationMap map[string]ActivationFunc `json:"-"`
 	Debug               bool                      `json:"-"`
 	TrainableNeurons    []int                     // New field: list of neuron IDs to train
+	Strict              bool                      `json:"-"`                        // Validate on load and revert mutations that break the network
+	ImprovementPolicy   ImprovementPolicy         `json:"-"`                        // Fallback for entry points given no policy; nil uses DefaultImprovementPolicy. Not saved
+	Metadata            *ModelMetadata            `json:"metadata,omitempty"`       // Model ID and lineage; filled in when a Genealogy is set
+	Genealogy           *Genealogy                `json:"-"`                        // Records lineage events of mutations, crossover, Grow and training
+	MutationSigma       float64                   `json:"mutation_sigma,omitempty"` // Per-individual step multiplier under self-adaptive mutation
+	Rand                *rand.Rand                `json:"-"`                        // Source of all of the Phase's randomness; nil uses the global math/rand. Not safe for concurrent use; see Copy for how copies are seeded
+
+	mutationDepth int        // Nesting of mutationCheckpoint calls, so only the outermost mutation is recorded
+	reverts       int        // Mutations strict mode has rolled back, so callers can tell kept changes apart
+	randSeed      int64      // Seed of seededRand; copies derive their seeds from it and copies
+	seededRand    *rand.Rand // The Rand randSeed belongs to, so a replaced Rand is noticed
+	copies        int64      // Copies made since seeding
 }
 
 // ModelMetadata holds metadata, evaluation benchmarks, and additional information for models in the AI framework.
@@ -95,9 +107,14 @@ func NewPhase() *Phase {
 
 // RandomWeights generates random weights for connections
 func (bp *Phase) RandomWeights(size int) []float64 {
+	return randomWeights(size, bp.rng())
+}
+
+// randomWeights is RandomWeights drawing from rng.
+func randomWeights(size int, rng *rand.Rand) []float64 {
 	weights := make([]float64, size)
 	for i := range weights {
-		weights[i] = rand.NormFloat64() * 0.5 // Increase scale
+		weights[i] = rng.NormFloat64() * 0.5 // Increase scale
 	}
 	return weights
 }
@@ -124,7 +141,15 @@ func (bp *Phase) ApplyScalarActivation(value float64, activation string) float64
 	return Linear(value)
 }
 
-// Forward propagates inputs through the network
+// Forward propagates inputs through the network. Each timestep processes the hidden neurons in
+// evaluation order, sources before the neurons they feed, and then the outputs.
+//
+// Forward used to process IDs 1..len(Neurons) in ID order, so saved models may compute
+// differently than before in two cases. Neurons with IDs above len(Neurons) were skipped, and
+// a hidden neuron fed by a hidden neuron with a higher ID read that neuron's value from the
+// previous timestep, or its reset value at the first, where it now reads the current one.
+// Networks whose connections all run from lower to higher IDs, such as those built by
+// NewPhaseWithLayers, compute the same as before.
 func (bp *Phase) Forward(inputs map[int]float64, timesteps int) {
 	bp.ResetNeuronValues()
 
@@ -138,7 +163,8 @@ func (bp *Phase) Forward(inputs map[int]float64, timesteps int) {
 		}
 	}
 
-	// Process neurons over timesteps
+	// Process neurons over timesteps, sources before the neurons they feed
+	order := bp.evaluationOrder()
 	for t := 0; t < timesteps; t++ {
 		if bp.Debug {
 			fmt.Printf("=== Timestep %d ===\n", t)
@@ -146,7 +172,7 @@ func (bp *Phase) Forward(inputs map[int]float64, timesteps int) {
 
 		// Process all neurons in two passes: hidden first, then outputs
 		// First pass: hidden neurons (including new ones)
-		for id := 1; id <= len(bp.Neurons); id++ {
+		for _, id := range order {
 			neuron, exists := bp.Neurons[id]
 			if !exists || neuron.Type == "input" || contains(bp.OutputNodes, id) {
 				continue
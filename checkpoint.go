@@ -42,14 +42,17 @@ func (bp *Phase) GetPreOutputNeurons() []int {
 	for _, outputID := range bp.OutputNodes {
 		outputNeuron := bp.Neurons[outputID]
 		for _, conn := range outputNeuron.Connections {
-			sourceID := int(conn[0])
-			sourceSet[sourceID] = struct{}{}
+			if !conn.Enabled {
+				continue
+			}
+			sourceSet[conn.Source] = struct{}{}
 		}
 	}
 	sourceIDs := []int{}
 	for id := range sourceSet {
 		sourceIDs = append(sourceIDs, id)
 	}
+	sort.Ints(sourceIDs) // Stable order keeps seeded runs reproducible
 	return sourceIDs
 }
 
@@ -88,12 +91,13 @@ func (bp *Phase) ForwardUpTo(inputs map[int]float64, timesteps int, exclude []in
 		excludeSet[id] = struct{}{}
 	}
 
-	// Process neurons over timesteps, skipping excluded and input neurons
+	// Process neurons over timesteps in evaluation order, skipping excluded and input neurons
+	order := bp.evaluationOrder()
 	for t := 0; t < timesteps; t++ {
 		if bp.Debug {
 			fmt.Printf("=== Timestep %d ===\n", t)
 		}
-		for id := 1; id <= len(bp.Neurons); id++ {
+		for _, id := range order {
 			if _, excluded := excludeSet[id]; excluded {
 				continue
 			}
@@ -103,8 +107,8 @@ func (bp *Phase) ForwardUpTo(inputs map[int]float64, timesteps int, exclude []in
 			}
 			inputValues := []float64{}
 			for _, conn := range neuron.Connections {
-				sourceID := int(conn[0])
-				weight := conn[1]
+				sourceID := conn.Source
+				weight := conn.effectiveWeight()
 				if sourceNeuron, exists := bp.Neurons[sourceID]; exists {
 					inputValues = append(inputValues, sourceNeuron.Value*weight)
 				}
@@ -169,7 +173,7 @@ func (bp *Phase) CheckpointPreOutputNeuronsMultiCore(checkpointFolder string, in
 	checkpoints := make([]map[int]map[string]interface{}, len(inputs))
 
 	// Worker pool setup
-	numWorkers := int(float64(runtime.NumCPU()) * 0.8) // Use 80% of CPU cores
+	numWorkers := multiCoreWorkers()
 	jobs := make(chan int, len(inputs))
 	results := make(chan struct {
 		index      int
@@ -178,14 +182,13 @@ func (bp *Phase) CheckpointPreOutputNeuronsMultiCore(checkpointFolder string, in
 	}, len(inputs))
 	var wg sync.WaitGroup
 
-	// Start workers
+	// Start workers, each with its own copy of the model
 	for w := 0; w < numWorkers; w++ {
 		wg.Add(1)
+		localBP := bp.workerCopy(w)
 		go func() {
 			defer wg.Done()
 			for i := range jobs {
-				// Each worker creates a fresh copy of the model to avoid race conditions
-				localBP := bp.Copy() // Assuming Copy() creates a deep copy of the Phase struct
 				inputMap := inputs[i]
 
 				// Run forward pass excluding output neurons
@@ -278,8 +281,8 @@ func (bp *Phase) ComputeOutputsFromCheckpoint(checkpoint map[int]map[string]inte
 		neuron := bp.Neurons[outID]
 		inputValues := []float64{}
 		for _, conn := range neuron.Connections {
-			sourceID := int(conn[0])
-			weight := conn[1]
+			sourceID := conn.Source
+			weight := conn.effectiveWeight()
 			if _, ok := preOutputSet[sourceID]; ok {
 				inputValues = append(inputValues, bp.Neurons[sourceID].Value*weight)
 			} else if sourceNeuron, exists := bp.Neurons[sourceID]; exists {
@@ -301,14 +304,22 @@ func (bp *Phase) ComputeOutputsFromCheckpoint(checkpoint map[int]map[string]inte
 // are taken from a random subset of the pre-output neurons, then adds a connection
 // from the new neuron to every output neuron (without removing existing connections).
 func (bp *Phase) AddNeuronFromPreOutputs(neuronType, activation string, minConnections, maxConnections int) *Neuron {
+	return bp.addNeuronFromPreOutputs(neuronType, activation, minConnections, maxConnections, defaultInitScale, bp.rng())
+}
+
+// addNeuronFromPreOutputs is AddNeuronFromPreOutputs with the standard deviation of the new
+// bias and incoming weights set by scale, drawing from rng.
+func (bp *Phase) addNeuronFromPreOutputs(neuronType, activation string, minConnections, maxConnections int, scale float64, rng *rand.Rand) *Neuron {
+	defer bp.mutationCheckpoint("AddNeuronFromPreOutputs", map[string]interface{}{"type": neuronType, "activation": activation, "min_connections": minConnections, "max_connections": maxConnections, "scale": scale})()
+
 	// If no activation is provided, choose one randomly from a predefined list.
 	if activation == "" {
-		activation = possibleActivations[rand.Intn(len(possibleActivations))]
+		activation = possibleActivations[rng.Intn(len(possibleActivations))]
 	}
 
 	// If no neuron type is provided, choose one randomly from a predefined list.
 	if neuronType == "" {
-		neuronType = neuronTypes[rand.Intn(len(neuronTypes))]
+		neuronType = neuronTypes[rng.Intn(len(neuronTypes))]
 	}
 
 	// Get the IDs of neurons that feed into the output layer.
@@ -318,32 +329,32 @@ func (bp *Phase) AddNeuronFromPreOutputs(neuronType, activation string, minConne
 	}
 
 	// Determine the number of incoming connections, constrained by available neurons.
-	numConns := rand.Intn(maxConnections-minConnections+1) + minConnections
+	numConns := rng.Intn(maxConnections-minConnections+1) + minConnections
 	if numConns > len(preOutputIDs) {
 		numConns = len(preOutputIDs)
 	}
 
 	// Shuffle the pre-output neuron IDs and select a subset for connections.
-	rand.Shuffle(len(preOutputIDs), func(i, j int) {
+	rng.Shuffle(len(preOutputIDs), func(i, j int) {
 		preOutputIDs[i], preOutputIDs[j] = preOutputIDs[j], preOutputIDs[i]
 	})
 	selectedIDs := preOutputIDs[:numConns]
 
-	// Create the new neuron with basic properties.
-	newID := bp.GetNextNeuronID()
+	// Create the new neuron with basic properties and a population-wide unique ID.
+	newID := Innovations.NodeID(bp.GetNextNeuronID())
 	newNeuron := &Neuron{
 		ID:          newID,
 		Type:        neuronType,
-		Bias:        rand.NormFloat64() * 0.1, // Small random bias
+		Bias:        rng.NormFloat64() * scale, // Small random bias
 		Activation:  activation,
-		Connections: make([][]float64, 0, numConns),
+		Connections: make([]Connection, 0, numConns),
 		IsNew:       true, // Mark as newly added
 	}
 
 	// Add incoming connections from the selected pre-output neurons with small random weights.
 	for _, srcID := range selectedIDs {
-		weight := rand.NormFloat64() * 0.1
-		newNeuron.Connections = append(newNeuron.Connections, []float64{float64(srcID), weight})
+		weight := rng.NormFloat64() * scale
+		newNeuron.Connections = append(newNeuron.Connections, newTrackedConnection(srcID, newID, weight))
 	}
 
 	// Initialize type-specific parameters based on neuronType.
@@ -352,30 +363,33 @@ func (bp *Phase) AddNeuronFromPreOutputs(neuronType, activation string, minConne
 		// Initialize gate weights for LSTM neurons based on the number of connections.
 		conCount := len(newNeuron.Connections)
 		newNeuron.GateWeights = map[string][]float64{
-			"input":  bp.RandomWeights(conCount), // Random weights for input gate
-			"forget": bp.RandomWeights(conCount), // Random weights for forget gate
-			"output": bp.RandomWeights(conCount), // Random weights for output gate
-			"cell":   bp.RandomWeights(conCount), // Random weights for cell gate
+			"input":  randomWeights(conCount, rng), // Random weights for input gate
+			"forget": randomWeights(conCount, rng), // Random weights for forget gate
+			"output": randomWeights(conCount, rng), // Random weights for output gate
+			"cell":   randomWeights(conCount, rng), // Random weights for cell gate
 		}
 	case "cnn":
 		// Randomize the number of kernels between 1 and 10 for CNN neurons.
-		numKernels := rand.Intn(10) + 1 // Generates a random integer from 1 to 10
+		numKernels := rng.Intn(10) + 1 // Generates a random integer from 1 to 10
 		newNeuron.Kernels = make([][]float64, numKernels)
+		// Kernels hold 2x2 = 4 elements, fewer when the neuron has fewer inputs, since longer
+		// kernels never fit and would leave the neuron stuck at 0.
+		kernelSize := min(4, len(newNeuron.Connections))
 		for i := 0; i < numKernels; i++ {
-			// Initialize each kernel as a 2x2 matrix with random values.
-			kernel := make([]float64, 4) // 2x2 = 4 elements
-			for j := 0; j < 4; j++ {
-				kernel[j] = rand.Float64() // Random float between 0 and 1
+			kernel := make([]float64, kernelSize)
+			for j := 0; j < kernelSize; j++ {
+				kernel[j] = rng.Float64() // Random float between 0 and 1
 			}
 			newNeuron.Kernels[i] = kernel
 		}
 	case "batch_norm":
 		// Initialize batch normalization parameters.
 		newNeuron.BatchNormParams = &BatchNormParams{
-			Gamma: 1.0, // Scaling factor
-			Beta:  0.0, // Shift factor
-			Mean:  0.0, // Running mean
-			Var:   1.0, // Running variance
+			Gamma:     1.0, // Scaling factor
+			Beta:      0.0, // Shift factor
+			Mean:      0.0, // Running mean
+			Var:       1.0, // Running variance
+			SumInputs: true,
 		}
 	default:
 		// For "dense" or unrecognized types, no additional initialization is required.
@@ -385,7 +399,7 @@ func (bp *Phase) AddNeuronFromPreOutputs(neuronType, activation string, minConne
 	bp.Neurons[newID] = newNeuron
 
 	// Connect the new neuron to every output neuron.
-	bp.AddNewNeuronToOutput(newID)
+	bp.addNewNeuronToOutput(newID, rng)
 
 	return newNeuron
 }
@@ -393,11 +407,16 @@ func (bp *Phase) AddNeuronFromPreOutputs(neuronType, activation string, minConne
 // AddNewNeuronToOutput connects the new neuron to every output neuron by adding
 // a new connection with a small random weight if one does not already exist.
 func (bp *Phase) AddNewNeuronToOutput(newNeuronID int) {
+	bp.addNewNeuronToOutput(newNeuronID, bp.rng())
+}
+
+// addNewNeuronToOutput is AddNewNeuronToOutput drawing the weights from rng.
+func (bp *Phase) addNewNeuronToOutput(newNeuronID int, rng *rand.Rand) {
 	for _, outID := range bp.OutputNodes {
 		outNeuron := bp.Neurons[outID]
 		if !bp.connectionExists(newNeuronID, outID) {
-			weight := rand.NormFloat64() * 0.1 // small random weight
-			outNeuron.Connections = append(outNeuron.Connections, []float64{float64(newNeuronID), weight})
+			weight := rng.NormFloat64() * 0.1 // small random weight
+			outNeuron.Connections = append(outNeuron.Connections, newTrackedConnection(newNeuronID, outID, weight))
 			if bp.Debug {
 				fmt.Printf("Added connection from new neuron %d to output neuron %d with weight %f\n", newNeuronID, outID, weight)
 			}
@@ -574,7 +593,11 @@ func (bp *Phase) ComputeOutputsWithNewNeurons(checkpoint map[int]map[string]inte
 		}
 	}
 
-	// Process neurons over timesteps
+	// Process neurons over timesteps, sources before the neurons they feed
+	var order []int
+	if hasNewNeurons {
+		order = bp.evaluationOrder()
+	}
 	for t := 0; t < timesteps; t++ {
 		if bp.Debug {
 			fmt.Printf("=== Processing Timestep %d ===\n", t)
@@ -582,7 +605,7 @@ func (bp *Phase) ComputeOutputsWithNewNeurons(checkpoint map[int]map[string]inte
 
 		if hasNewNeurons {
 			// First pass: only new neurons
-			for id := 1; id <= len(bp.Neurons); id++ {
+			for _, id := range order {
 				neuron, exists := bp.Neurons[id]
 				if !exists || !neuron.IsNew {
 					continue
@@ -614,8 +637,8 @@ func (bp *Phase) ComputeOutputsWithNewNeurons(checkpoint map[int]map[string]inte
 func (bp *Phase) gatherInputs(neuron *Neuron) []float64 {
 	inputValues := make([]float64, 0, len(neuron.Connections))
 	for _, conn := range neuron.Connections {
-		sourceID := int(conn[0]) // The ID of the source neuron
-		weight := conn[1]        // The connection weight
+		sourceID := conn.Source          // The ID of the source neuron
+		weight := conn.effectiveWeight() // Disabled connections contribute 0
 		if sourceNeuron, exists := bp.Neurons[sourceID]; exists {
 			inputValues = append(inputValues, sourceNeuron.Value*weight)
 		} else {
@@ -644,8 +667,9 @@ func (bp *Phase) ComputeOutputsWithNewNeuronsFromCheckpoint(checkpoint map[int]m
 		}
 	}
 
-	// Process neurons not in the checkpoint (new neurons), excluding inputs
-	for id, neuron := range bp.Neurons {
+	// Process neurons not in the checkpoint (new neurons), excluding inputs, sources first
+	for _, id := range bp.evaluationOrder() {
+		neuron := bp.Neurons[id]
 		if _, inCheckpoint := checkpoint[id]; !inCheckpoint && neuron.Type != "input" {
 			inputValues := bp.gatherInputs(neuron)
 			bp.ProcessNeuron(neuron, inputValues, 0)
@@ -679,8 +703,10 @@ func (bp *Phase) ComputePartialOutputsFromCheckpoint(checkpoint map[int]map[stri
 			bp.SetNeuronState(neuron, state)
 		}
 	}
-	// Process neurons that are not in the checkpoint (new neurons).
-	for id, neuron := range bp.Neurons {
+	// Process neurons that are not in the checkpoint (new neurons), sources first so that
+	// chains of new neurons see each other's fresh values.
+	for _, id := range bp.evaluationOrder() {
+		neuron := bp.Neurons[id]
 		if neuron.Type == "input" {
 			continue
 		}
@@ -833,7 +859,7 @@ func (bp *Phase) EvaluateWithCheckpointsMultiCore(checkpointFolder string, check
 	sampleWeight := 100.0 / float64(nSamples)
 
 	// Worker pool setup
-	numWorkers := int(float64(runtime.NumCPU()) * 0.8)
+	numWorkers := multiCoreWorkers()
 	jobs := make(chan int, nSamples)
 	results := make(chan struct {
 		exactMatch   float64
@@ -843,9 +869,11 @@ func (bp *Phase) EvaluateWithCheckpointsMultiCore(checkpointFolder string, check
 	}, nSamples)
 	var wg sync.WaitGroup
 
-	// Start workers
+	// Start workers. Evaluating overwrites neuron values, so each worker runs its own copy of
+	// the model rather than sharing bp.
 	for w := 0; w < numWorkers; w++ {
 		wg.Add(1)
+		localBP := bp.workerCopy(w)
 		go func() {
 			defer wg.Done()
 			for i := range jobs {
@@ -865,9 +893,9 @@ func (bp *Phase) EvaluateWithCheckpointsMultiCore(checkpointFolder string, check
 
 				var outputs map[int]float64
 				if checkpointFolder == "" {
-					outputs = bp.ComputePartialOutputsFromCheckpoint((*checkpoints)[i])
+					outputs = localBP.ComputePartialOutputsFromCheckpoint((*checkpoints)[i])
 				} else {
-					checkpoint, err := bp.LoadCheckpoint(checkpointFolder, i)
+					checkpoint, err := localBP.LoadCheckpoint(checkpointFolder, i)
 					if err != nil {
 						if bp.Debug {
 							fmt.Printf("Sample %d: Failed to load checkpoint: %v, skipping\n", i, err)
@@ -880,11 +908,11 @@ func (bp *Phase) EvaluateWithCheckpointsMultiCore(checkpointFolder string, check
 						}{0, -1, 0, err}
 						continue
 					}
-					outputs = bp.ComputePartialOutputsFromCheckpoint(checkpoint)
+					outputs = localBP.ComputePartialOutputsFromCheckpoint(checkpoint)
 				}
 
 				vals := make([]float64, numOutputs)
-				for j, outID := range bp.OutputNodes {
+				for j, outID := range localBP.OutputNodes {
 					v := outputs[outID]
 					if math.IsNaN(v) || math.IsInf(v, 0) {
 						v = 0
@@ -915,7 +943,7 @@ func (bp *Phase) EvaluateWithCheckpointsMultiCore(checkpointFolder string, check
 					}
 				}
 
-				approx := bp.CalculatePercentageMatch(float64(label), float64(predClass))
+				approx := localBP.CalculatePercentageMatch(float64(label), float64(predClass))
 				partialCredit := approx / 100.0
 
 				if bp.Debug {
@@ -972,3 +1000,12 @@ func (bp *Phase) EvaluateWithCheckpointsMultiCore(checkpointFolder string, check
 
 	return exactAcc, closenessBins, approxScore
 }
+
+// multiCoreWorkers returns the worker count of the MultiCore helpers: 80% of the CPU cores,
+// but at least one.
+func multiCoreWorkers() int {
+	if n := int(float64(runtime.NumCPU()) * 0.8); n > 0 {
+		return n
+	}
+	return 1
+}
@@ -59,7 +59,7 @@ func (bp *Phase) ManualClampWeights(minVal, maxVal float64) {
 	}
 	for _, neuron := range bp.Neurons {
 		for i := range neuron.Connections {
-			w := neuron.Connections[i][1]
+			w := neuron.Connections[i].Weight
 			if math.IsNaN(w) || math.IsInf(w, 0) {
 				w = 0
 			} else if w > maxVal {
@@ -67,7 +67,7 @@ func (bp *Phase) ManualClampWeights(minVal, maxVal float64) {
 			} else if w < minVal {
 				w = minVal
 			}
-			neuron.Connections[i][1] = w
+			neuron.Connections[i].Weight = w
 		}
 	}
 }
@@ -111,7 +111,7 @@ func (bp *Phase) ManualClampAll(minVal, maxVal float64) {
 
 		// Clamp Weights
 		for i := range neuron.Connections {
-			w := neuron.Connections[i][1]
+			w := neuron.Connections[i].Weight
 			if math.IsNaN(w) || math.IsInf(w, 0) {
 				w = 0
 			} else if w > maxVal {
@@ -119,7 +119,7 @@ func (bp *Phase) ManualClampAll(minVal, maxVal float64) {
 			} else if w < minVal {
 				w = minVal
 			}
-			neuron.Connections[i][1] = w
+			neuron.Connections[i].Weight = w
 		}
 	}
 }
@@ -0,0 +1,121 @@
+package phase
+
+import "fmt"
+
+// CompactIDs renumbers the neurons densely from 0, keeping their relative order, and updates
+// connection sources, NCA neighborhoods, the sources of quantum neuron connections,
+// InputNodes, OutputNodes and TrainableNeurons to match. Quantum neurons keep their IDs and
+// the neurons are numbered around them. It returns the mapping from old to new IDs; pass it
+// to RemapCheckpoints or RemapCheckpointFolder to keep existing checkpoints usable.
+//
+// Connections keep their innovation numbers, and each is registered with the Innovations
+// tracker under its new endpoints, so connections added between the renumbered neurons later
+// get the same numbers. This replaces what the tracker held for those pairs, so compact
+// finished models: a Phase still evolving alongside others would see its numbers change.
+func (bp *Phase) CompactIDs() map[int]int {
+	defer bp.mutationCheckpoint("CompactIDs", nil)()
+
+	mapping := make(map[int]int, len(bp.Neurons))
+	newID := 0
+	for _, oldID := range bp.sortedNeuronIDs() {
+		for bp.QuantumNeurons[newID] != nil {
+			newID++
+		}
+		mapping[oldID] = newID
+		newID++
+	}
+
+	neurons := make(map[int]*Neuron, len(bp.Neurons))
+	for oldID, neuron := range bp.Neurons {
+		newID := mapping[oldID]
+		neuron.ID = newID
+		for i := range neuron.Connections {
+			if source, exists := mapping[neuron.Connections[i].Source]; exists {
+				neuron.Connections[i].Source = source
+			}
+		}
+		if neuron.NeighborhoodIDs != nil {
+			neuron.NeighborhoodIDs = remapIDs(neuron.NeighborhoodIDs, mapping)
+		}
+		neurons[newID] = neuron
+	}
+	bp.Neurons = neurons
+	bp.InputNodes = remapIDs(bp.InputNodes, mapping)
+	bp.OutputNodes = remapIDs(bp.OutputNodes, mapping)
+	if bp.TrainableNeurons != nil {
+		bp.TrainableNeurons = remapIDs(bp.TrainableNeurons, mapping)
+	}
+	for _, qn := range bp.QuantumNeurons {
+		for _, conn := range qn.Connections {
+			if len(conn) == 0 {
+				continue
+			}
+			// The first entry holds the source ID in its real part.
+			if source, exists := mapping[int(real(conn[0]))]; exists {
+				conn[0] = complex(float64(source), imag(conn[0]))
+			}
+		}
+	}
+
+	for _, id := range bp.sortedNeuronIDs() {
+		for _, conn := range bp.Neurons[id].Connections {
+			if conn.Innovation != 0 {
+				Innovations.register(conn.Source, id, conn.Innovation)
+			}
+		}
+	}
+
+	if bp.Debug {
+		fmt.Printf("Compacted neuron IDs to 0..%d\n", newID-1)
+	}
+	return mapping
+}
+
+// remapIDs translates ids through mapping, dropping IDs that have no new number.
+func remapIDs(ids []int, mapping map[int]int) []int {
+	remapped := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if newID, exists := mapping[id]; exists {
+			remapped = append(remapped, newID)
+		}
+	}
+	return remapped
+}
+
+// RemapCheckpoint returns a copy of checkpoint keyed by the new neuron IDs. States of neurons
+// that no longer exist are dropped.
+func RemapCheckpoint(checkpoint map[int]map[string]interface{}, mapping map[int]int) map[int]map[string]interface{} {
+	if checkpoint == nil {
+		return nil
+	}
+	remapped := make(map[int]map[string]interface{}, len(checkpoint))
+	for oldID, state := range checkpoint {
+		if newID, exists := mapping[oldID]; exists {
+			remapped[newID] = state
+		}
+	}
+	return remapped
+}
+
+// RemapCheckpoints remaps in-memory checkpoints in place. Nil entries, which stand for
+// checkpoints saved to files, are left alone.
+func RemapCheckpoints(checkpoints []map[int]map[string]interface{}, mapping map[int]int) {
+	for i, checkpoint := range checkpoints {
+		checkpoints[i] = RemapCheckpoint(checkpoint, mapping)
+	}
+}
+
+// RemapCheckpointFolder rewrites the checkpoint files sample_0.json to sample_<count-1>.json
+// in checkpointFolder with the new neuron IDs.
+func (bp *Phase) RemapCheckpointFolder(checkpointFolder string, count int, mapping map[int]int) error {
+	for i := 0; i < count; i++ {
+		checkpoint, err := bp.LoadCheckpoint(checkpointFolder, i)
+		if err != nil {
+			return err
+		}
+		if err := bp.SaveCheckpoint(checkpointFolder, i, RemapCheckpoint(checkpoint, mapping)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
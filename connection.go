@@ -0,0 +1,90 @@
+package phase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Connection is a weighted incoming edge from a source neuron.
+type Connection struct {
+	Source     int     // ID of the source neuron
+	Weight     float64 // Connection weight
+	Enabled    bool    // Disabled connections contribute nothing but keep their place (NEAT-style)
+	Innovation int     // Historical marking used to align genes during crossover (0 = unassigned)
+}
+
+// NewConnection returns an enabled connection from sourceID with the given weight.
+func NewConnection(sourceID int, weight float64) Connection {
+	return Connection{Source: sourceID, Weight: weight, Enabled: true}
+}
+
+// connectionJSON is the object form of a serialized Connection.
+type connectionJSON struct {
+	Source     int     `json:"source"`
+	Weight     float64 `json:"weight"`
+	Enabled    *bool   `json:"enabled,omitempty"`
+	Innovation int     `json:"innovation,omitempty"`
+}
+
+// MarshalJSON writes plain enabled connections in the legacy [source_id, weight] pair format
+// and everything else as an object, so files stay readable by older versions where possible.
+func (c Connection) MarshalJSON() ([]byte, error) {
+	if c.Enabled && c.Innovation == 0 {
+		return json.Marshal([2]float64{float64(c.Source), c.Weight})
+	}
+	enabled := c.Enabled
+	return json.Marshal(connectionJSON{
+		Source:     c.Source,
+		Weight:     c.Weight,
+		Enabled:    &enabled,
+		Innovation: c.Innovation,
+	})
+}
+
+// UnmarshalJSON accepts both the legacy [source_id, weight] pair and the object format.
+// Connections without an explicit "enabled" field are enabled.
+func (c *Connection) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var pair []float64
+		if err := json.Unmarshal(trimmed, &pair); err != nil {
+			return fmt.Errorf("invalid connection pair: %w", err)
+		}
+		if len(pair) < 2 {
+			return fmt.Errorf("invalid connection pair: expected [source_id, weight], got %d values", len(pair))
+		}
+		*c = NewConnection(int(pair[0]), pair[1])
+		return nil
+	}
+
+	var aux connectionJSON
+	if err := json.Unmarshal(trimmed, &aux); err != nil {
+		return fmt.Errorf("invalid connection: %w", err)
+	}
+	*c = Connection{
+		Source:     aux.Source,
+		Weight:     aux.Weight,
+		Enabled:    aux.Enabled == nil || *aux.Enabled,
+		Innovation: aux.Innovation,
+	}
+	return nil
+}
+
+// effectiveWeight returns the weight used during the forward pass: 0 for disabled connections.
+func (c Connection) effectiveWeight() float64 {
+	if !c.Enabled {
+		return 0
+	}
+	return c.Weight
+}
+
+// connectionIndex returns the position of the connection from sourceID in conns, or -1.
+func connectionIndex(conns []Connection, sourceID int) int {
+	for i, conn := range conns {
+		if conn.Source == sourceID {
+			return i
+		}
+	}
+	return -1
+}
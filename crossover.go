@@ -1,73 +1,223 @@
 package phase
 
-import "math/rand"
+import (
+	"math/rand"
+	"sort"
+)
 
-// crossoverPhases merges two parent Phases to create an offspring Phase.
+// crossoverPhases merges two parent Phases of unknown fitness to create an offspring Phase.
 func crossoverPhases(parentA, parentB *Phase) *Phase {
+	return Crossover(parentA, parentB, 0, 0)
+}
+
+// Crossover combines two parents NEAT-style and returns the offspring.
+//
+// Connection genes are aligned by innovation number (see InnovationTracker). Matching genes take
+// their weight from a random parent and stay disabled with 75% probability when either parent has
+// them disabled. Disjoint and excess genes are inherited from the fitter parent, or from both when
+// the fitness is equal. Neurons follow the genes: every neuron of the fitter parent is kept, and a
+// neuron present in both parents takes its type, bias and activation from a random one. LSTM gate
+// weights are realigned to the inherited connections by source. Input and output nodes come from
+// the fitter parent (a on ties). Quantum neurons are not inherited. The random choices are drawn
+// from a's Rand; when a has one, the offspring gets its own Rand seeded from it.
+func Crossover(a, b *Phase, fitnessA, fitnessB float64) *Phase {
+	return crossover(a, b, fitnessA, fitnessB, a.rng())
+}
+
+// crossover is Crossover drawing its random choices from rng.
+func crossover(a, b *Phase, fitnessA, fitnessB float64, rng *rand.Rand) *Phase {
+	fitter, other := a, b
+	if fitnessB > fitnessA {
+		fitter, other = b, a
+	}
+	equal := fitnessA == fitnessB
+
 	offspring := NewPhase()
+	offspring.ID = rng.Intn(10000) + 1 // Like GetNextPhaseID, but from rng so the parents' streams are left alone
+	if a.Rand != nil {
+		offspring.seedRand(rng.Int63())
+	}
+	offspring.Debug = a.Debug
+	offspring.Strict = a.Strict
+	offspring.ImprovementPolicy = a.ImprovementPolicy
+	offspring.Genealogy = a.Genealogy
+	if offspring.Genealogy == nil {
+		offspring.Genealogy = b.Genealogy
+	}
+	offspring.InputNodes = append([]int{}, fitter.InputNodes...)
+	offspring.OutputNodes = append([]int{}, fitter.OutputNodes...)
 
-	// 1. Merge Neurons (Random Selection from Both Parents)
-	for id, neuronA := range parentA.Neurons {
-		if neuronB, exists := parentB.Neurons[id]; exists {
-			offspring.Neurons[id] = deepCopyNeuron(selectNeuron(neuronA, neuronB))
+	// 1. Node genes: the neuron each offspring neuron was copied from.
+	origin := make(map[int]*Neuron)
+	for _, id := range fitter.sortedNeuronIDs() {
+		neuron := fitter.Neurons[id]
+		if twin, exists := other.Neurons[id]; exists {
+			neuron = selectNeuron(neuron, twin, rng)
+			activation := selectActivation(fitter.Neurons[id].Activation, twin.Activation, rng)
+			offspring.Neurons[id] = deepCopyNeuron(neuron)
+			offspring.Neurons[id].Activation = activation
 		} else {
-			offspring.Neurons[id] = deepCopyNeuron(neuronA)
+			offspring.Neurons[id] = deepCopyNeuron(neuron)
 		}
+		origin[id] = neuron
 	}
-
-	for id, neuronB := range parentB.Neurons {
-		if _, exists := offspring.Neurons[id]; !exists {
-			offspring.Neurons[id] = deepCopyNeuron(neuronB)
+	if equal {
+		for _, id := range other.sortedNeuronIDs() {
+			if _, exists := offspring.Neurons[id]; !exists {
+				offspring.Neurons[id] = deepCopyNeuron(other.Neurons[id])
+				origin[id] = other.Neurons[id]
+			}
 		}
 	}
 
-	// 2. Merge Connections (Randomly Inherit or Average)
-	mergeNeuronConnections(offspring, parentA, parentB)
+	// 2. Connection genes, aligned by innovation.
+	for _, id := range offspring.sortedNeuronIDs() {
+		child := offspring.Neurons[id]
+		genesF := connectionGenes(fitter.Neurons[id], id)
+		genesO := connectionGenes(other.Neurons[id], id)
 
-	// 3. Apply Activation Crossover (Random Selection)
-	applyActivationCrossover(offspring, parentA, parentB)
+		inherited := make(map[int]Connection)
+		for _, innovation := range sortedGeneInnovations(genesF) {
+			geneF := genesF[innovation]
+			if geneO, matching := genesO[innovation]; matching {
+				inherited[innovation] = crossMatchingGene(geneF, geneO, rng)
+			} else {
+				inherited[innovation] = geneF
+			}
+		}
+		if equal {
+			for _, innovation := range sortedGeneInnovations(genesO) {
+				geneO := genesO[innovation]
+				if _, exists := inherited[innovation]; !exists {
+					inherited[innovation] = geneO
+				}
+			}
+		}
+
+		child.Connections = orderInheritedGenes(inherited, origin[id], id, offspring)
+		if child.Type == "lstm" {
+			alignGateWeights(child, rng, origin[id], fitter.Neurons[id], other.Neurons[id])
+		}
+	}
 
-	// 4. Ensure All Output Neurons Exist
-	ensureOutputNeurons(offspring, parentA.OutputNodes)
+	// 3. Ensure All Output Neurons Exist
+	ensureOutputNeurons(offspring, offspring.OutputNodes)
 
+	offspring.recordLineage("Crossover", map[string]interface{}{"fitness_a": fitnessA, "fitness_b": fitnessB}, nil, a, b)
 	return offspring
 }
 
-// selectNeuron randomly chooses a neuron from either of the parents.
-func selectNeuron(neuronA, neuronB *Neuron) *Neuron {
-	if rand.Float64() < 0.5 {
-		return neuronA
+// connectionGenes indexes a neuron's incoming connections by innovation number.
+func connectionGenes(neuron *Neuron, id int) map[int]Connection {
+	genes := make(map[int]Connection)
+	if neuron == nil {
+		return genes
 	}
-	return neuronB
+	for _, conn := range neuron.Connections {
+		conn.Innovation = connectionInnovation(conn, id)
+		genes[conn.Innovation] = conn
+	}
+	return genes
 }
 
-// mergeNeuronConnections merges neuron connections from both parents.
-func mergeNeuronConnections(offspring, parentA, parentB *Phase) {
-	for id, neuron := range offspring.Neurons {
-		if parentA.Neurons[id] != nil && parentB.Neurons[id] != nil {
-			for i := range neuron.Connections {
-				if rand.Float64() < 0.5 {
-					neuron.Connections[i][1] = parentA.Neurons[id].Connections[i][1]
-				} else {
-					neuron.Connections[i][1] = parentB.Neurons[id].Connections[i][1]
-				}
+// sortedGeneInnovations returns the innovation numbers of genes in ascending order.
+func sortedGeneInnovations(genes map[int]Connection) []int {
+	innovations := make([]int, 0, len(genes))
+	for innovation := range genes {
+		innovations = append(innovations, innovation)
+	}
+	sort.Ints(innovations)
+	return innovations
+}
+
+// crossMatchingGene combines a gene present in both parents: the weight comes from a random
+// parent, and a gene disabled in either parent stays disabled with 75% probability.
+func crossMatchingGene(geneA, geneB Connection, rng *rand.Rand) Connection {
+	gene := geneA
+	if rng.Float64() < 0.5 {
+		gene = geneB
+	}
+	gene.Enabled = true
+	if !geneA.Enabled || !geneB.Enabled {
+		gene.Enabled = rng.Float64() >= 0.75
+	}
+	return gene
+}
+
+// orderInheritedGenes lays out the inherited genes of neuron id. Connections keep the order they
+// had in the origin neuron, since CNN and LSTM neurons depend on input order; the remaining genes
+// follow in innovation order. Genes whose source is missing from the offspring are dropped.
+func orderInheritedGenes(inherited map[int]Connection, origin *Neuron, id int, offspring *Phase) []Connection {
+	conns := make([]Connection, 0, len(inherited))
+	placed := make(map[int]bool, len(inherited))
+	add := func(innovation int) {
+		gene := inherited[innovation]
+		if _, exists := offspring.Neurons[gene.Source]; exists {
+			conns = append(conns, gene)
+		}
+		placed[innovation] = true
+	}
+
+	if origin != nil {
+		for _, conn := range origin.Connections {
+			innovation := connectionInnovation(conn, id)
+			if _, exists := inherited[innovation]; exists && !placed[innovation] {
+				add(innovation)
 			}
 		}
 	}
+	rest := []int{}
+	for innovation := range inherited {
+		if !placed[innovation] {
+			rest = append(rest, innovation)
+		}
+	}
+	sort.Ints(rest)
+	for _, innovation := range rest {
+		add(innovation)
+	}
+	return conns
 }
 
-// applyActivationCrossover selects the activation function from either parent.
-func applyActivationCrossover(offspring, parentA, parentB *Phase) {
-	for id, neuron := range offspring.Neurons {
-		if parentA.Neurons[id] != nil && parentB.Neurons[id] != nil {
-			neuron.Activation = selectActivation(parentA.Neurons[id].Activation, parentB.Neurons[id].Activation)
+// alignGateWeights rebuilds an LSTM neuron's gate weights so that they line up with its
+// connections. Each weight is looked up by source in the parents, in order; weights that no
+// parent has are drawn at random.
+func alignGateWeights(child *Neuron, rng *rand.Rand, parents ...*Neuron) {
+	gates := make(map[string][]float64, len(lstmGates))
+	for _, gate := range lstmGates {
+		weights := make([]float64, len(child.Connections))
+		for i, conn := range child.Connections {
+			found := false
+			for _, parent := range parents {
+				if parent == nil || parent.Type != "lstm" {
+					continue
+				}
+				if j := connectionIndex(parent.Connections, conn.Source); j >= 0 && j < len(parent.GateWeights[gate]) {
+					weights[i] = parent.GateWeights[gate][j]
+					found = true
+					break
+				}
+			}
+			if !found {
+				weights[i] = rng.NormFloat64() * 0.5
+			}
 		}
+		gates[gate] = weights
+	}
+	child.GateWeights = gates
+}
+
+// selectNeuron randomly chooses a neuron from either of the parents.
+func selectNeuron(neuronA, neuronB *Neuron, rng *rand.Rand) *Neuron {
+	if rng.Float64() < 0.5 {
+		return neuronA
 	}
+	return neuronB
 }
 
 // selectActivation randomly chooses an activation function from either parent.
-func selectActivation(activationA, activationB string) string {
-	if rand.Float64() < 0.5 {
+func selectActivation(activationA, activationB string, rng *rand.Rand) string {
+	if rng.Float64() < 0.5 {
 		return activationA
 	}
 	return activationB
@@ -105,6 +255,8 @@ func deepCopyNeuron(n *Neuron) *Neuron {
 		BatchNorm:   n.BatchNorm,
 		Attention:   n.Attention,
 		CellState:   n.CellState,
+		UpdateRules: n.UpdateRules,
+		IsNew:       n.IsNew,
 	}
 
 	// Deep copy arrays and maps
@@ -127,14 +279,11 @@ func copyFloat64Slice(dst *[]float64, src []float64) {
 	}
 }
 
-// copyNeuronConnections safely copies neuron connection weights.
-func copyNeuronConnections(dst *[][]float64, src [][]float64) {
+// copyNeuronConnections safely copies neuron connections.
+func copyNeuronConnections(dst *[]Connection, src []Connection) {
 	if len(src) > 0 {
-		*dst = make([][]float64, len(src))
-		for i, conn := range src {
-			(*dst)[i] = make([]float64, len(conn))
-			copy((*dst)[i], conn)
-		}
+		*dst = make([]Connection, len(src))
+		copy(*dst, src)
 	}
 }
 
@@ -165,10 +314,11 @@ func copyKernels(dst *[][]float64, src [][]float64) {
 func copyBatchNormParams(dst **BatchNormParams, src *BatchNormParams) {
 	if src != nil {
 		*dst = &BatchNormParams{
-			Gamma: src.Gamma,
-			Beta:  src.Beta,
-			Mean:  src.Mean,
-			Var:   src.Var,
+			Gamma:     src.Gamma,
+			Beta:      src.Beta,
+			Mean:      src.Mean,
+			Var:       src.Var,
+			SumInputs: src.SumInputs,
 		}
 	}
 }
@@ -0,0 +1,139 @@
+package phase
+
+import "context"
+
+// FineTuneScope selects the neurons a fine-tune trains. The output neurons are always trained
+// too, since their incoming weights connect the rest of the subset to the result.
+type FineTuneScope string
+
+const (
+	FineTuneNew       FineTuneScope = "new"        // Neurons added by the accepted growth step
+	FineTunePreOutput FineTuneScope = "pre_output" // Neurons with an enabled connection into an output
+	FineTuneAll       FineTuneScope = "all"        // Every non-input neuron
+)
+
+// FineTuneConfig configures the gradient fine-tune GrowWithConfig runs after each accepted
+// growth step; see GrowConfig.FineTune.
+type FineTuneConfig struct {
+	Scope        FineTuneScope // Defaults to FineTuneNew
+	Epochs       int           // Passes over the samples; defaults to 1
+	LearningRate float64       // Passed to TrainNetworkTargeted, which caps it at 0.1; defaults to 0.001
+	ClampMin     float64       // Weight and bias bounds; both zero means [-10, 10]
+	ClampMax     float64
+}
+
+// withDefaults returns cfg with unset fields filled in.
+func (cfg FineTuneConfig) withDefaults() FineTuneConfig {
+	if cfg.Scope == "" {
+		cfg.Scope = FineTuneNew
+	}
+	if cfg.Epochs < 1 {
+		cfg.Epochs = 1
+	}
+	if cfg.LearningRate <= 0 {
+		cfg.LearningRate = 0.001
+	}
+	if cfg.ClampMin >= cfg.ClampMax {
+		cfg.ClampMin, cfg.ClampMax = -10, 10
+	}
+	return cfg
+}
+
+// retrainsOriginal reports whether the fine-tune can change neurons of the model Grow started
+// from, whose values the checkpoints hold.
+func (cfg *FineTuneConfig) retrainsOriginal() bool {
+	return cfg != nil && cfg.withDefaults().Scope != FineTuneNew
+}
+
+// FineTune trains the neurons listed in TrainableNeurons on samples with TrainNetworkTargeted,
+// for cfg.Epochs passes in sample order.
+func (bp *Phase) FineTune(samples []Sample, cfg FineTuneConfig) {
+	cfg = cfg.withDefaults()
+	for epoch := 0; epoch < cfg.Epochs; epoch++ {
+		for _, sample := range samples {
+			bp.TrainNetworkTargeted(sample.Inputs, sample.ExpectedOutputs, cfg.LearningRate, cfg.ClampMin, cfg.ClampMax, bp.TrainableNeurons)
+		}
+	}
+}
+
+// fineTuneNeurons returns the neurons of bp that scope selects, plus the outputs, in ID order.
+// parent is the model bp was grown from.
+func fineTuneNeurons(scope FineTuneScope, bp, parent *Phase) []int {
+	preOutputs := bp.GetPreOutputNeurons()
+	selected := []int{}
+	for _, id := range bp.sortedNeuronIDs() {
+		neuron := bp.Neurons[id]
+		if neuron.Type == "input" {
+			continue
+		}
+		switch {
+		case contains(bp.OutputNodes, id), scope == FineTuneAll:
+		case scope == FineTunePreOutput:
+			if !contains(preOutputs, id) {
+				continue
+			}
+		default:
+			if _, existed := parent.Neurons[id]; existed {
+				continue
+			}
+		}
+		selected = append(selected, id)
+	}
+	return selected
+}
+
+// fineTune trains a copy of the freshly accepted best model and keeps it if it improves under
+// the run's policy. When the fine-tune reaches checkpointed neurons, the copy is evaluated on
+// checkpoints rebuilt from it, and those replace the run's checkpoints if the copy is kept.
+func (r *growRun) fineTune(ctx context.Context, parent *Phase) {
+	cfg := r.cfg.FineTune.withDefaults()
+	tuned := r.best.Copy()
+	tuned.TrainableNeurons = fineTuneNeurons(cfg.Scope, tuned, parent)
+	tuned.FineTune(*r.samples, cfg)
+
+	checkpoints, rebuilt := r.checkpoints, r.rebuilt
+	if cfg.Scope != FineTuneNew {
+		checkpoints, rebuilt = r.checkpointsFor(tuned), true
+	}
+	folder := r.cfg.CheckpointFolder
+	if rebuilt {
+		folder = ""
+	}
+	exactAcc, closenessBins, approxScore := r.evaluateWith(tuned, folder, checkpoints)
+	closeness := r.policy.ClosenessQuality(closenessBins)
+	improvement := r.policy.Improvement(ModelResult{
+		ExactAcc:      exactAcc,
+		ClosenessBins: closenessBins,
+		ApproxScore:   approxScore,
+	}, r.bestExactAcc, r.bestClosenessQ, r.bestApproxScore)
+
+	r.emit(ctx, GrowEvent{Kind: GrowEventFineTune, Iteration: r.iterations, Improvement: improvement,
+		PreviousExactAcc: r.bestExactAcc, ExactAcc: exactAcc, Closeness: closeness, ApproxScore: approxScore,
+		NeuronsAdded: r.neuronsAdded})
+	if improvement <= 0 {
+		return
+	}
+	r.best = tuned
+	r.bestExactAcc = exactAcc
+	r.bestClosenessBins = closenessBins
+	r.bestClosenessQ = closeness
+	r.bestApproxScore = approxScore
+	r.checkpoints, r.rebuilt = checkpoints, rebuilt
+}
+
+// checkpointsFor rebuilds the run's checkpoints from candidate: the states of the checkpointed
+// neurons after a one-timestep forward pass over each sample.
+func (r *growRun) checkpointsFor(candidate *Phase) *[]map[int]map[string]interface{} {
+	checkpoints := make([]map[int]map[string]interface{}, len(*r.samples))
+	for i, sample := range *r.samples {
+		candidate.ForwardUpTo(sample.Inputs, 1, candidate.OutputNodes)
+		checkpoint := make(map[int]map[string]interface{}, len(r.scope.checkpointed))
+		for id := range r.scope.checkpointed {
+			if neuron, exists := candidate.Neurons[id]; exists {
+				checkpoint[id] = candidate.GetNeuronState(neuron)
+			}
+		}
+		checkpoints[i] = checkpoint
+	}
+	return &checkpoints
+}
@@ -2,6 +2,8 @@ module phase
 
 go 1.23.3
 
+require gopkg.in/yaml.v3 v3.0.1
+
 require (
 	github.com/go-gl/gl v0.0.0-20231021071112-07e5d0ea2e71 // indirect
 	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20240506104042-037f3cc74f2a // indirect
@@ -0,0 +1,351 @@
+package phase
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+	"strings"
+)
+
+// GraphExportOptions controls how ExportDOT and ExportMermaid render a Phase.
+type GraphExportOptions struct {
+	ClusterByLayer  bool    // Group neurons into subgraphs by inferred layer
+	WeightThreshold float64 // Omit connections whose absolute weight is below this value
+	ShowQuantum     bool    // Include quantum neurons, their connections and entanglements
+	MaxEdgeWidth    float64 // Width of the strongest edge (default 4)
+}
+
+// neuronTypeColors maps neuron types to fill colors used in graph exports.
+var neuronTypeColors = map[string]string{
+	"input":      "#c6dbef",
+	"dense":      "#fdd0a2",
+	"rnn":        "#c7e9c0",
+	"lstm":       "#a1d99b",
+	"cnn":        "#dadaeb",
+	"batch_norm": "#fcbba1",
+	"dropout":    "#d9d9d9",
+	"attention":  "#fee391",
+	"nca":        "#9ecae1",
+	"quantum":    "#e7cbf5",
+}
+
+// graphEdge is a connection prepared for export.
+type graphEdge struct {
+	from, to string
+	weight   float64
+	quantum  bool
+	disabled bool
+}
+
+// ExportDOT renders the neuron graph in Graphviz DOT format.
+// Nodes are colored by type and labeled with activation and bias; edge width follows the absolute weight.
+// Input neurons are boxes, outputs are double octagons and neurons marked IsNew get a red outline.
+func (bp *Phase) ExportDOT(opts GraphExportOptions) string {
+	var sb strings.Builder
+	edges, maxAbs := bp.graphEdges(opts)
+
+	fmt.Fprintf(&sb, "digraph phase_%d {\n", bp.ID)
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [style=filled, fontname=\"Helvetica\", fontsize=10];\n")
+	sb.WriteString("  edge [arrowsize=0.6];\n")
+
+	writeNode := func(indent string, id int) {
+		neuron := bp.Neurons[id]
+		attrs := []string{
+			fmt.Sprintf("label=\"%s\"", strings.ReplaceAll(bp.graphNodeLabel(neuron), "\n", "\\n")),
+			fmt.Sprintf("fillcolor=\"%s\"", graphTypeColor(neuron.Type)),
+		}
+		switch {
+		case neuron.Type == "input" || contains(bp.InputNodes, id):
+			attrs = append(attrs, "shape=box")
+		case contains(bp.OutputNodes, id):
+			attrs = append(attrs, "shape=doubleoctagon")
+		default:
+			attrs = append(attrs, "shape=ellipse")
+		}
+		if neuron.IsNew {
+			attrs = append(attrs, "color=\"#d62728\"", "penwidth=3")
+		}
+		fmt.Fprintf(&sb, "%sn%d [%s];\n", indent, id, strings.Join(attrs, ", "))
+	}
+
+	if opts.ClusterByLayer {
+		for i, layer := range bp.InferLayers() {
+			fmt.Fprintf(&sb, "  subgraph cluster_layer_%d {\n", i)
+			fmt.Fprintf(&sb, "    label=\"layer %d\";\n    style=dashed;\n    color=\"#999999\";\n", i)
+			for _, id := range layer {
+				writeNode("    ", id)
+			}
+			sb.WriteString("  }\n")
+		}
+	} else {
+		for _, id := range bp.sortedNeuronIDs() {
+			writeNode("  ", id)
+		}
+	}
+
+	if opts.ShowQuantum {
+		for _, id := range bp.sortedQuantumNeuronIDs() {
+			qn := bp.QuantumNeurons[id]
+			fmt.Fprintf(&sb, "  q%d [label=\"%s\", fillcolor=\"%s\", shape=diamond];\n",
+				id, strings.ReplaceAll(graphQuantumLabel(qn), "\n", "\\n"), graphTypeColor("quantum"))
+		}
+	}
+
+	for _, e := range edges {
+		color := "#1f77b4"
+		if e.weight < 0 {
+			color = "#d62728"
+		}
+		style := "solid"
+		if e.quantum {
+			color = "#9467bd"
+			style = "dashed"
+		}
+		if e.disabled {
+			color = "#bbbbbb"
+			style = "dotted"
+		}
+		fmt.Fprintf(&sb, "  %s -> %s [penwidth=%.2f, color=\"%s\", style=%s, tooltip=\"%.4f\"];\n",
+			e.from, e.to, graphEdgeWidth(e.weight, maxAbs, opts.MaxEdgeWidth), color, style, e.weight)
+	}
+
+	if opts.ShowQuantum {
+		for _, pair := range bp.quantumEntanglementPairs() {
+			fmt.Fprintf(&sb, "  q%d -> q%d [dir=none, style=dotted, color=\"#9467bd\", label=\"%s\"];\n",
+				pair.a, pair.b, pair.kind)
+		}
+	}
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// ExportMermaid renders the neuron graph as a Mermaid flowchart with the same styling rules as ExportDOT.
+func (bp *Phase) ExportMermaid(opts GraphExportOptions) string {
+	var sb strings.Builder
+	edges, maxAbs := bp.graphEdges(opts)
+
+	sb.WriteString("flowchart LR\n")
+
+	writeNode := func(indent string, id int) {
+		neuron := bp.Neurons[id]
+		label := strings.ReplaceAll(bp.graphNodeLabel(neuron), "\n", "<br/>")
+		switch {
+		case neuron.Type == "input" || contains(bp.InputNodes, id):
+			fmt.Fprintf(&sb, "%sn%d[\"%s\"]\n", indent, id, label)
+		case contains(bp.OutputNodes, id):
+			fmt.Fprintf(&sb, "%sn%d{{\"%s\"}}\n", indent, id, label)
+		default:
+			fmt.Fprintf(&sb, "%sn%d([\"%s\"])\n", indent, id, label)
+		}
+	}
+
+	if opts.ClusterByLayer {
+		for i, layer := range bp.InferLayers() {
+			fmt.Fprintf(&sb, "  subgraph layer_%d [\"layer %d\"]\n", i, i)
+			for _, id := range layer {
+				writeNode("    ", id)
+			}
+			sb.WriteString("  end\n")
+		}
+	} else {
+		for _, id := range bp.sortedNeuronIDs() {
+			writeNode("  ", id)
+		}
+	}
+
+	if opts.ShowQuantum {
+		for _, id := range bp.sortedQuantumNeuronIDs() {
+			label := strings.ReplaceAll(graphQuantumLabel(bp.QuantumNeurons[id]), "\n", "<br/>")
+			fmt.Fprintf(&sb, "  q%d{\"%s\"}\n", id, label)
+		}
+	}
+
+	linkStyles := []string{}
+	for i, e := range edges {
+		arrow := "-->"
+		color := "#1f77b4"
+		if e.weight < 0 {
+			color = "#d62728"
+		}
+		if e.quantum {
+			arrow = "-.->"
+			color = "#9467bd"
+		}
+		if e.disabled {
+			arrow = "-.->"
+			color = "#bbbbbb"
+		}
+		fmt.Fprintf(&sb, "  %s %s|%.3f| %s\n", e.from, arrow, e.weight, e.to)
+		linkStyles = append(linkStyles, fmt.Sprintf("  linkStyle %d stroke:%s,stroke-width:%.2fpx\n",
+			i, color, graphEdgeWidth(e.weight, maxAbs, opts.MaxEdgeWidth)))
+	}
+	if opts.ShowQuantum {
+		for _, pair := range bp.quantumEntanglementPairs() {
+			fmt.Fprintf(&sb, "  q%d -.-|%s| q%d\n", pair.a, pair.kind, pair.b)
+		}
+	}
+
+	// Class definitions for neuron types.
+	types := []string{}
+	for t := range neuronTypeColors {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		fmt.Fprintf(&sb, "  classDef %s fill:%s,stroke:#555555\n", t, neuronTypeColors[t])
+	}
+	sb.WriteString("  classDef unknown fill:#ffffff,stroke:#555555\n")
+	for _, id := range bp.sortedNeuronIDs() {
+		neuron := bp.Neurons[id]
+		class := neuron.Type
+		if _, known := neuronTypeColors[class]; !known {
+			class = "unknown"
+		}
+		fmt.Fprintf(&sb, "  class n%d %s\n", id, class)
+		if neuron.IsNew {
+			fmt.Fprintf(&sb, "  style n%d stroke:#d62728,stroke-width:3px\n", id)
+		}
+	}
+	if opts.ShowQuantum {
+		for _, id := range bp.sortedQuantumNeuronIDs() {
+			fmt.Fprintf(&sb, "  class q%d quantum\n", id)
+		}
+	}
+	for _, ls := range linkStyles {
+		sb.WriteString(ls)
+	}
+	return sb.String()
+}
+
+// graphEdges collects the connections to export and the largest absolute weight among them.
+func (bp *Phase) graphEdges(opts GraphExportOptions) ([]graphEdge, float64) {
+	edges := []graphEdge{}
+	maxAbs := 0.0
+	for _, id := range bp.sortedNeuronIDs() {
+		for _, conn := range bp.Neurons[id].Connections {
+			srcID := conn.Source
+			weight := conn.Weight
+			if math.Abs(weight) < opts.WeightThreshold {
+				continue
+			}
+			from := fmt.Sprintf("n%d", srcID)
+			if _, exists := bp.Neurons[srcID]; !exists {
+				if _, isQuantum := bp.QuantumNeurons[srcID]; !isQuantum || !opts.ShowQuantum {
+					continue
+				}
+				from = fmt.Sprintf("q%d", srcID)
+			}
+			edges = append(edges, graphEdge{from: from, to: fmt.Sprintf("n%d", id), weight: weight, disabled: !conn.Enabled})
+			maxAbs = math.Max(maxAbs, math.Abs(weight))
+		}
+	}
+
+	if opts.ShowQuantum {
+		for _, id := range bp.sortedQuantumNeuronIDs() {
+			for _, conn := range bp.QuantumNeurons[id].Connections {
+				if len(conn) < 2 {
+					continue
+				}
+				srcID := int(real(conn[0]))
+				weight := cmplx.Abs(conn[1])
+				if weight < opts.WeightThreshold {
+					continue
+				}
+				from := fmt.Sprintf("q%d", srcID)
+				if _, exists := bp.Neurons[srcID]; exists {
+					from = fmt.Sprintf("n%d", srcID)
+				} else if _, exists := bp.QuantumNeurons[srcID]; !exists {
+					continue
+				}
+				edges = append(edges, graphEdge{from: from, to: fmt.Sprintf("q%d", id), weight: weight, quantum: true})
+				maxAbs = math.Max(maxAbs, weight)
+			}
+		}
+	}
+	return edges, maxAbs
+}
+
+// graphNodeLabel builds the multi-line label for a neuron.
+func (bp *Phase) graphNodeLabel(neuron *Neuron) string {
+	if neuron.Type == "input" {
+		return fmt.Sprintf("%d\ninput", neuron.ID)
+	}
+	activation := neuron.Activation
+	if activation == "" {
+		activation = "linear"
+	}
+	return fmt.Sprintf("%d\n%s %s\nb=%.3f", neuron.ID, neuron.Type, activation, neuron.Bias)
+}
+
+// graphQuantumLabel builds the label for a quantum neuron from its ID and gates.
+func graphQuantumLabel(qn *QuantumNeuron) string {
+	gates := []string{}
+	for _, gate := range qn.QuantumGates {
+		gates = append(gates, gate.Type)
+	}
+	if len(gates) == 0 {
+		return fmt.Sprintf("%d\nquantum", qn.ID)
+	}
+	return fmt.Sprintf("%d\nquantum\n%s", qn.ID, strings.Join(gates, ","))
+}
+
+// graphTypeColor returns the fill color for a neuron type.
+func graphTypeColor(neuronType string) string {
+	if color, exists := neuronTypeColors[neuronType]; exists {
+		return color
+	}
+	return "#ffffff"
+}
+
+// graphEdgeWidth scales an edge width in [0.5, maxWidth] by the absolute weight.
+func graphEdgeWidth(weight, maxAbs, maxWidth float64) float64 {
+	if maxWidth <= 0 {
+		maxWidth = 4
+	}
+	if maxAbs == 0 {
+		return 0.5
+	}
+	return 0.5 + (maxWidth-0.5)*math.Abs(weight)/maxAbs
+}
+
+// sortedQuantumNeuronIDs returns the IDs of all quantum neurons in ascending order.
+func (bp *Phase) sortedQuantumNeuronIDs() []int {
+	ids := make([]int, 0, len(bp.QuantumNeurons))
+	for id := range bp.QuantumNeurons {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// quantumPair is an undirected entanglement between two quantum neurons.
+type quantumPair struct {
+	a, b int
+	kind string
+}
+
+// quantumEntanglementPairs lists each entanglement once, ordered by neuron ID.
+func (bp *Phase) quantumEntanglementPairs() []quantumPair {
+	seen := make(map[[2]int]bool)
+	pairs := []quantumPair{}
+	for _, id := range bp.sortedQuantumNeuronIDs() {
+		for _, ent := range bp.QuantumNeurons[id].Entanglements {
+			a, b := id, ent.PartnerID
+			if a > b {
+				a, b = b, a
+			}
+			if seen[[2]int{a, b}] {
+				continue
+			}
+			if _, exists := bp.QuantumNeurons[ent.PartnerID]; !exists {
+				continue
+			}
+			seen[[2]int{a, b}] = true
+			pairs = append(pairs, quantumPair{a: a, b: b, kind: ent.Type})
+		}
+	}
+	return pairs
+}
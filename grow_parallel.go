@@ -0,0 +1,203 @@
+package phase
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+)
+
+// GrowEventKind identifies what a GrowEvent reports.
+type GrowEventKind string
+
+const (
+	GrowEventIteration   GrowEventKind = "iteration"   // A candidate was evaluated
+	GrowEventImprovement GrowEventKind = "improvement" // A candidate replaced the sandbox's best model
+	GrowEventDone        GrowEventKind = "done"        // A sandbox finished its run
+	GrowEventMerge       GrowEventKind = "merge"       // GrowParallel finished a round
+	GrowEventFineTune    GrowEventKind = "fine_tune"   // An accepted candidate was fine-tuned; see GrowConfig.FineTune
+)
+
+// GrowEvent reports the progress of Grow. Metrics describe the candidate for iteration and
+// improvement events, the fine-tuned copy for fine-tune events, and the best model for done
+// and merge events.
+type GrowEvent struct {
+	Kind                GrowEventKind
+	WorkerID            int // Sandbox; for merge events the sandbox whose model was kept, or -1
+	Round               int // GrowParallel round, from 0
+	Iteration           int
+	ConsecutiveFailures int
+	ExactAcc            float64
+	Closeness           float64
+	ApproxScore         float64
+	Improvement         float64
+	NeuronsAdded        int
+	PreviousExactAcc    float64 // Exact accuracy before the fine-tune, for fine-tune events
+}
+
+// String formats the event as the progress line Grow prints when no Events channel is set.
+func (e GrowEvent) String() string {
+	switch e.Kind {
+	case GrowEventIteration:
+		return fmt.Sprintf("Sandbox %d, Iter %d: eA=%.4f, cQ=%.4f, aS=%.4f, Neurons=%d",
+			e.WorkerID, e.Iteration, e.ExactAcc, e.Closeness, e.ApproxScore, e.NeuronsAdded)
+	case GrowEventImprovement:
+		return fmt.Sprintf("Sandbox %d: Improvement at Iter %d: Total Improvement=%.4f, eA=%.4f, cQ=%.4f, aS=%.4f, Neurons=%d",
+			e.WorkerID, e.Iteration, e.Improvement, e.ExactAcc, e.Closeness, e.ApproxScore, e.NeuronsAdded)
+	case GrowEventDone:
+		return fmt.Sprintf("Sandbox %d: Exited after %d iterations, %d consecutive failures, eA=%.4f, cQ=%.4f, aS=%.4f",
+			e.WorkerID, e.Iteration, e.ConsecutiveFailures, e.ExactAcc, e.Closeness, e.ApproxScore)
+	case GrowEventFineTune:
+		outcome := "kept"
+		if e.Improvement <= 0 {
+			outcome = "discarded"
+		}
+		return fmt.Sprintf("Sandbox %d: Fine-tune at Iter %d %s: eA %.4f -> %.4f, Improvement=%.4f, cQ=%.4f, aS=%.4f",
+			e.WorkerID, e.Iteration, outcome, e.PreviousExactAcc, e.ExactAcc, e.Improvement, e.Closeness, e.ApproxScore)
+	case GrowEventMerge:
+		if e.WorkerID < 0 {
+			return fmt.Sprintf("Round %d: no sandbox improved, eA=%.4f, cQ=%.4f, aS=%.4f",
+				e.Round, e.ExactAcc, e.Closeness, e.ApproxScore)
+		}
+		return fmt.Sprintf("Round %d: merged Sandbox %d: Total Improvement=%.4f, eA=%.4f, cQ=%.4f, aS=%.4f, Neurons=%d",
+			e.Round, e.WorkerID, e.Improvement, e.ExactAcc, e.Closeness, e.ApproxScore, e.NeuronsAdded)
+	}
+	return fmt.Sprintf("Sandbox %d: %s", e.WorkerID, e.Kind)
+}
+
+// GrowParallelConfig configures GrowParallel.
+type GrowParallelConfig struct {
+	Grow        GrowConfig                        // Settings of every sandbox; WorkerID, Events and Rand are set per sandbox
+	Sandboxes   int                               // Grow runs per round; defaults to runtime.NumCPU()
+	Workers     int                               // Sandboxes running at once; defaults to min(Sandboxes, runtime.NumCPU())
+	Rounds      int                               // Grow-and-merge rounds; defaults to 1
+	Samples     *[]Sample                         // Samples the checkpoints were made from
+	Checkpoints *[]map[int]map[string]interface{} // Pre-output checkpoints, shared read-only by every sandbox
+	Events      chan<- GrowEvent                  // Receives every sandbox's events plus one merge event per round; closed on return
+}
+
+// GrowParallel grows bp in rounds. Each round queues cfg.Sandboxes independent Grow runs from
+// the current best model, lets cfg.Workers goroutines work through the queue, and then keeps
+// the sandbox result that improves most on the current best under cfg.Grow.Policy, or bp's
+// ImprovementPolicy when that is nil. It stops after cfg.Rounds rounds, after a round in which
+// no sandbox improved, or when ctx is done; sandboxes check ctx between iterations. It returns the best model found, whose NeuronsAdded
+// is its neuron count minus bp's, and ctx.Err() if the run was cut short.
+func (bp *Phase) GrowParallel(ctx context.Context, cfg GrowParallelConfig) (ModelResult, error) {
+	if cfg.Events != nil {
+		defer close(cfg.Events)
+	}
+	if cfg.Sandboxes < 1 {
+		cfg.Sandboxes = runtime.NumCPU()
+	}
+	if cfg.Workers < 1 {
+		cfg.Workers = min(cfg.Sandboxes, runtime.NumCPU())
+	}
+	if cfg.Rounds < 1 {
+		cfg.Rounds = 1
+	}
+	send := func(event GrowEvent) {
+		if cfg.Events == nil {
+			return
+		}
+		select {
+		case cfg.Events <- event:
+		case <-ctx.Done():
+		}
+	}
+
+	policy := bp.resolvePolicy(cfg.Grow.Policy)
+	best := ModelResult{BP: bp.Copy()}
+	labels := GetLabels(cfg.Samples, best.BP.OutputNodes)
+	if cfg.Grow.EvalWithMultiCore {
+		best.ExactAcc, best.ClosenessBins, best.ApproxScore = best.BP.EvaluateWithCheckpointsMultiCore(cfg.Grow.CheckpointFolder, cfg.Checkpoints, labels)
+	} else {
+		best.ExactAcc, best.ClosenessBins, best.ApproxScore = best.BP.EvaluateWithCheckpoints(cfg.Grow.CheckpointFolder, cfg.Checkpoints, labels)
+	}
+
+	for round := 0; round < cfg.Rounds && ctx.Err() == nil; round++ {
+		events := make(chan GrowEvent)
+		forwarded := make(chan struct{})
+		go func() {
+			defer close(forwarded)
+			for event := range events {
+				event.Round = round
+				send(event)
+			}
+		}()
+
+		// A *rand.Rand is not safe for concurrent use, so each sandbox gets its own, seeded
+		// from cfg.Grow.Rand, or else bp's Rand, in queue order.
+		source := cfg.Grow.Rand
+		if source == nil {
+			source = bp.Rand
+		}
+		sandboxRands := make([]*rand.Rand, cfg.Sandboxes)
+		if source != nil {
+			for i := range sandboxRands {
+				sandboxRands[i] = childRand(source)
+			}
+		}
+		// The sandboxes are copied here in queue order, since the copies' IDs depend on the
+		// order in which the best model is copied.
+		sandboxes := make([]*Phase, cfg.Sandboxes)
+		for i := range sandboxes {
+			sandboxes[i] = best.BP.Copy()
+			if sandboxRands[i] != nil {
+				sandboxes[i].seedRand(sandboxRands[i].Int63())
+			}
+		}
+
+		jobs := make(chan int, cfg.Sandboxes)
+		for i := 0; i < cfg.Sandboxes; i++ {
+			jobs <- i
+		}
+		close(jobs)
+
+		results := make([]ModelResult, cfg.Sandboxes)
+		var wg sync.WaitGroup
+		for w := 0; w < cfg.Workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					if ctx.Err() != nil {
+						continue
+					}
+					growCfg := cfg.Grow
+					growCfg.WorkerID = i
+					growCfg.Events = events
+					growCfg.Rand = sandboxRands[i]
+					sandbox := sandboxes[i]
+					results[i], _ = sandbox.GrowWithContext(ctx, growCfg, sandbox, cfg.Samples, cfg.Checkpoints)
+				}
+			}()
+		}
+		wg.Wait()
+		close(events)
+		<-forwarded
+
+		// Merge: keep the sandbox result that improves most on the current best.
+		bestCloseness := policy.ClosenessQuality(best.ClosenessBins)
+		winner, winnerImprovement := -1, 0.0
+		for i, result := range results {
+			if result.BP == nil {
+				continue
+			}
+			if improvement := policy.Improvement(result, best.ExactAcc, bestCloseness, best.ApproxScore); improvement > winnerImprovement {
+				winner, winnerImprovement = i, improvement
+			}
+		}
+		if winner >= 0 {
+			best = results[winner]
+		}
+		best.NeuronsAdded = len(best.BP.Neurons) - len(bp.Neurons)
+		send(GrowEvent{Kind: GrowEventMerge, WorkerID: winner, Round: round, Improvement: winnerImprovement,
+			ExactAcc: best.ExactAcc, Closeness: policy.ClosenessQuality(best.ClosenessBins), ApproxScore: best.ApproxScore,
+			NeuronsAdded: best.NeuronsAdded})
+		if winner < 0 {
+			break
+		}
+	}
+	return best, ctx.Err()
+}
@@ -0,0 +1,222 @@
+package phase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+)
+
+// GrowSession is a Grow run that can survive its process. It saves its full state to a file
+// every SaveEvery iterations: the best model and its metrics, the iteration and failure
+// counters, the state of its seeded random source, the global Innovations tracker and the
+// configuration, including the checkpoint folder. ResumeGrowSession picks the run up from that
+// file, and the result matches an uninterrupted run with the same seed.
+//
+// Novelty search is not supported, since its archive lives outside the session. Policy,
+// Config.Events and a Genealogy on the model are not saved; set them again after resuming.
+type GrowSession struct {
+	FileName  string            // Where the state is saved
+	SaveEvery int               // Iterations between saves; defaults to 10
+	Policy    ImprovementPolicy // Compares candidates; set from Config.Policy or the original's; nil uses DefaultImprovementPolicy()
+	Config    GrowConfig        // Rand is replaced by the session's seeded source
+
+	source      *CountingSource
+	run         *growRun
+	samples     *[]Sample
+	checkpoints *[]map[int]map[string]interface{}
+}
+
+// growSessionFile is the on-disk form of a GrowSession.
+type growSessionFile struct {
+	Config              GrowConfig      `json:"config"`
+	SaveEvery           int             `json:"save_every"`
+	Seed                int64           `json:"seed"`
+	Draws               uint64          `json:"draws"`
+	Samples             int             `json:"samples"` // Number of checkpoints the run was started with
+	Best                *Phase          `json:"best"`
+	BestRand            *randState      `json:"best_rand,omitempty"` // How Copy seeds copies of Best
+	ExactAcc            float64         `json:"exact_acc"`
+	ClosenessBins       []float64       `json:"closeness_bins"`
+	ApproxScore         float64         `json:"approx_score"`
+	Iterations          int             `json:"iterations"`
+	ConsecutiveFailures int             `json:"consecutive_failures"`
+	NeuronsAdded        int             `json:"neurons_added"`
+	Checkpointed        []int           `json:"checkpointed"` // growthScope.checkpointed
+	Original            []int           `json:"original"`     // growthScope.original
+	Innovations         json.RawMessage `json:"innovations"`  // The global Innovations tracker
+}
+
+// NewGrowSession starts a session growing a copy of originalBP with cfg, seeded with seed,
+// evaluates the starting model and saves the initial state to fileName.
+func NewGrowSession(fileName string, originalBP *Phase, cfg GrowConfig, seed int64, samples *[]Sample, checkpoints *[]map[int]map[string]interface{}) (*GrowSession, error) {
+	if cfg.Novelty != nil {
+		return nil, fmt.Errorf("grow session: novelty search cannot be resumed and is not supported")
+	}
+	s := &GrowSession{
+		FileName:    fileName,
+		Policy:      originalBP.resolvePolicy(cfg.Policy),
+		Config:      cfg,
+		source:      NewCountingSource(seed),
+		samples:     samples,
+		checkpoints: checkpoints,
+	}
+	s.run = newGrowRun(s.policy(), s.runConfig(), rand.New(s.source), samples, checkpoints)
+	s.run.start(originalBP.Copy(), newGrowthScope(originalBP))
+	if err := s.Save(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ResumeGrowSession loads a session saved by GrowSession. samples and checkpoints must be the
+// ones the session was started with; with a checkpoint folder, checkpoints only needs the
+// right length. The global Innovations tracker is restored to its saved state.
+func ResumeGrowSession(fileName string, samples *[]Sample, checkpoints *[]map[int]map[string]interface{}) (*GrowSession, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read grow session '%s': %v", fileName, err)
+	}
+	var file growSessionFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse grow session '%s': %v", fileName, err)
+	}
+	if file.Best == nil {
+		return nil, fmt.Errorf("grow session '%s' has no model", fileName)
+	}
+	if len(*checkpoints) != file.Samples {
+		return nil, fmt.Errorf("grow session: started with %d checkpoints, resumed with %d", file.Samples, len(*checkpoints))
+	}
+	if len(file.Innovations) > 0 {
+		if err := Innovations.UnmarshalJSON(file.Innovations); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &GrowSession{
+		FileName:    fileName,
+		SaveEvery:   file.SaveEvery,
+		Config:      file.Config,
+		source:      RestoreCountingSource(file.Seed, file.Draws),
+		samples:     samples,
+		checkpoints: checkpoints,
+	}
+	scope := &growthScope{checkpointed: make(map[int]bool), original: make(map[int]bool)}
+	for _, id := range file.Checkpointed {
+		scope.checkpointed[id] = true
+	}
+	for _, id := range file.Original {
+		scope.original[id] = true
+	}
+	run := newGrowRun(s.policy(), s.runConfig(), rand.New(s.source), samples, checkpoints)
+	run.best = file.Best.Copy() // Copy restores what JSON leaves out, such as the activations
+	run.best.ID = file.Best.ID
+	run.best.restoreRandState(file.BestRand)
+	run.scope = scope
+	if run.cfg.FineTune.retrainsOriginal() {
+		// The saved model may have been fine-tuned since the given checkpoints were taken.
+		run.checkpoints, run.rebuilt = run.checkpointsFor(run.best), true
+	}
+	run.bestExactAcc = file.ExactAcc
+	run.bestClosenessBins = file.ClosenessBins
+	run.bestClosenessQ = run.policy.ClosenessQuality(file.ClosenessBins)
+	run.bestApproxScore = file.ApproxScore
+	run.iterations = file.Iterations
+	run.consecutiveFailures = file.ConsecutiveFailures
+	run.neuronsAdded = file.NeuronsAdded
+	s.run = run
+	return s, nil
+}
+
+// policy returns the session's ImprovementPolicy, or the default blend when none is set.
+func (s *GrowSession) policy() ImprovementPolicy {
+	if s.Policy == nil {
+		return DefaultImprovementPolicy()
+	}
+	return s.Policy
+}
+
+// runConfig returns Config with the session's random source.
+func (s *GrowSession) runConfig() GrowConfig {
+	cfg := s.Config
+	cfg.Rand = nil // The run draws from the session's source instead
+	return cfg
+}
+
+// Run grows until the iteration or failure budget is used up or ctx is done, saving every
+// SaveEvery iterations and once more before returning. It returns the best model so far and
+// the first save error or ctx.Err().
+func (s *GrowSession) Run(ctx context.Context) (ModelResult, error) {
+	if s.SaveEvery < 1 {
+		s.SaveEvery = 10
+	}
+	s.run.policy = s.policy()
+	s.run.cfg = s.runConfig()
+	for !s.run.done() && ctx.Err() == nil {
+		s.run.step(ctx)
+		if s.run.iterations%s.SaveEvery == 0 {
+			if err := s.Save(); err != nil {
+				return s.run.result(), err
+			}
+		}
+	}
+	if s.run.done() {
+		s.run.finish(ctx)
+	}
+	if err := s.Save(); err != nil {
+		return s.run.result(), err
+	}
+	return s.run.result(), ctx.Err()
+}
+
+// Done reports whether the session has used up its budget.
+func (s *GrowSession) Done() bool {
+	return s.run.done()
+}
+
+// Result returns the best model found so far.
+func (s *GrowSession) Result() ModelResult {
+	return s.run.result()
+}
+
+// Save writes the session state to FileName atomically, so a crash mid-write leaves the
+// previous state intact.
+func (s *GrowSession) Save() error {
+	seed, draws := s.source.State()
+	file := growSessionFile{
+		Config:              s.Config,
+		SaveEvery:           s.SaveEvery,
+		Seed:                seed,
+		Draws:               draws,
+		Samples:             len(*s.checkpoints),
+		Best:                s.run.best,
+		BestRand:            s.run.best.saveRandState(),
+		ExactAcc:            s.run.bestExactAcc,
+		ClosenessBins:       s.run.bestClosenessBins,
+		ApproxScore:         s.run.bestApproxScore,
+		Iterations:          s.run.iterations,
+		ConsecutiveFailures: s.run.consecutiveFailures,
+		NeuronsAdded:        s.run.neuronsAdded,
+	}
+	innovations, err := json.Marshal(Innovations)
+	if err != nil {
+		return fmt.Errorf("failed to serialize innovations: %v", err)
+	}
+	file.Innovations = innovations
+	for id := range s.run.scope.checkpointed {
+		file.Checkpointed = append(file.Checkpointed, id)
+	}
+	for id := range s.run.scope.original {
+		file.Original = append(file.Original, id)
+	}
+	sort.Ints(file.Checkpointed)
+	sort.Ints(file.Original)
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize grow session: %v", err)
+	}
+	return writeFileAtomic(s.FileName, data)
+}
@@ -0,0 +1,337 @@
+package phase
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// GrowthMode selects how GrowWithConfig enlarges a candidate.
+type GrowthMode string
+
+const (
+	GrowWidth GrowthMode = "width" // Add a neuron in parallel between the pre-outputs and the outputs
+	GrowDepth GrowthMode = "depth" // Split a connection into an output or grown neuron
+	GrowSkip  GrowthMode = "skip"  // Connect a neuron to one at least two layers further on
+)
+
+// SplitConnection replaces the enabled connection from source to target with a path through a
+// new neuron (NEAT add-node). The new neuron is a linear dense neuron with zero bias fed by a
+// weight of 1, and it passes the old weight on to target, so the network computes the same
+// function. The old connection is disabled rather than removed, except into cnn and attention
+// neurons, where it is rewritten in place (see redirectConnection). Recurrent connections and
+// connections leaving an output neuron cannot be split. It returns the new neuron, or nil.
+func (bp *Phase) SplitConnection(source, target int) *Neuron {
+	defer bp.mutationCheckpoint("SplitConnection", map[string]interface{}{"source": source, "target": target})()
+
+	if !bp.splittable(source, target, bp.inferDepths()) {
+		return nil
+	}
+	return bp.splitConnection(source, target)
+}
+
+// SplitRandomConnection splits a random connection with SplitConnection and returns the new
+// neuron, or nil when no connection can be split.
+func (bp *Phase) SplitRandomConnection() *Neuron {
+	defer bp.mutationCheckpoint("SplitRandomConnection", nil)()
+	return bp.splitRandomConnection(nil, bp.rng())
+}
+
+// splitRandomConnection splits a random splittable connection for which allowed, if set,
+// returns true, picked with rng.
+func (bp *Phase) splitRandomConnection(allowed func(source, target int) bool, rng *rand.Rand) *Neuron {
+	depth := bp.inferDepths()
+	candidates := [][2]int{}
+	for _, targetID := range bp.sortedNeuronIDs() {
+		for _, conn := range bp.Neurons[targetID].Connections {
+			if !bp.splittable(conn.Source, targetID, depth) {
+				continue
+			}
+			if allowed != nil && !allowed(conn.Source, targetID) {
+				continue
+			}
+			candidates = append(candidates, [2]int{conn.Source, targetID})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	pick := candidates[rng.Intn(len(candidates))]
+	return bp.splitConnection(pick[0], pick[1])
+}
+
+// splittable reports whether the connection from source to target exists, is enabled and
+// feeds forward from a neuron that is not an output.
+func (bp *Phase) splittable(source, target int, depth map[int]int) bool {
+	targetNeuron, exists := bp.Neurons[target]
+	if !exists {
+		return false
+	}
+	if _, exists := bp.Neurons[source]; !exists || contains(bp.OutputNodes, source) {
+		return false
+	}
+	if depth[source] >= depth[target] {
+		return false
+	}
+	for _, conn := range targetNeuron.Connections {
+		if conn.Source == source {
+			return conn.Enabled
+		}
+	}
+	return false
+}
+
+// splitConnection routes the connection from source to target through a new relay neuron.
+func (bp *Phase) splitConnection(source, target int) *Neuron {
+	relay := bp.newRelayNeuron(source)
+	targetNeuron := bp.Neurons[target]
+	for i, conn := range targetNeuron.Connections {
+		if conn.Source == source {
+			redirectConnection(targetNeuron, i, relay.ID)
+			break
+		}
+	}
+	if bp.Debug {
+		fmt.Printf("Split connection %d -> %d through new Neuron %d\n", source, target, relay.ID)
+	}
+	return relay
+}
+
+// newRelayNeuron adds a linear dense neuron that copies source's value.
+func (bp *Phase) newRelayNeuron(source int) *Neuron {
+	id := Innovations.NodeID(bp.GetNextNeuronID())
+	relay := &Neuron{
+		ID:          id,
+		Type:        "dense",
+		Activation:  "linear",
+		Connections: []Connection{newTrackedConnection(source, id, 1)},
+		IsNew:       true,
+	}
+	bp.Neurons[id] = relay
+	return relay
+}
+
+// redirectConnection moves target's connection at index onto newSource with the same weight.
+// The old connection is disabled and a new one appended; LSTM targets get a copy of the gate
+// weights at index, so the gates see the same input as before. Targets that read their inputs
+// by position would see them shift, and even a disabled connection still takes a slot, so for
+// those the connection is rewritten in place under its new innovation number instead.
+func redirectConnection(target *Neuron, index int, newSource int) {
+	if readsInputsByPosition(target) {
+		target.Connections[index].Source = newSource
+		target.Connections[index].Innovation = Innovations.Connection(newSource, target.ID)
+		return
+	}
+	target.Connections[index].Enabled = false
+	copyConnection(target, index, newSource, target.Connections[index].Weight)
+}
+
+// readsInputsByPosition reports whether the neuron's output depends on the order and number
+// of its inputs rather than just their sum: cnn neurons convolve them and attention neurons
+// weigh them against each other.
+func readsInputsByPosition(neuron *Neuron) bool {
+	return neuron.Type == "cnn" || neuron.Type == "attention"
+}
+
+// copyConnection adds a connection into target from newSource with the given weight. LSTM
+// targets get a copy of the gate weights at index for it.
+func copyConnection(target *Neuron, index int, newSource int, weight float64) {
+	target.Connections = append(target.Connections, newTrackedConnection(newSource, target.ID, weight))
+	if target.Type != "lstm" {
+		return
+	}
+	for gate, weights := range target.GateWeights {
+		if index < len(weights) && len(weights) == len(target.Connections)-1 {
+			target.GateWeights[gate] = append(weights, weights[index])
+		}
+	}
+}
+
+// InsertHiddenLayer inserts a new layer right after the inferred layer at index after (see
+// InferLayers; 0 is the input layer). Every neuron of that layer with connections into later
+// layers gets a linear relay neuron, and those connections are redirected through it, so the
+// network computes the same function. Connections that skip over the layer and recurrent
+// connections are left alone. It returns the IDs of the new neurons, or nil when after is not
+// followed by another layer.
+func (bp *Phase) InsertHiddenLayer(after int) []int {
+	defer bp.mutationCheckpoint("InsertHiddenLayer", map[string]interface{}{"after": after})()
+	return bp.insertHiddenLayer(after)
+}
+
+func (bp *Phase) insertHiddenLayer(after int) []int {
+	layers := bp.InferLayers()
+	if after < 0 || after >= len(layers)-1 {
+		return nil
+	}
+	layerOf := make(map[int]int, len(bp.Neurons))
+	for i, layer := range layers {
+		for _, id := range layer {
+			layerOf[id] = i
+		}
+	}
+
+	targets := []int{}
+	for _, id := range bp.sortedNeuronIDs() {
+		if layerOf[id] > after {
+			targets = append(targets, id)
+		}
+	}
+
+	inserted := []int{}
+	for _, sourceID := range layers[after] {
+		if contains(bp.OutputNodes, sourceID) {
+			continue
+		}
+		var relay *Neuron
+		for _, targetID := range targets {
+			target := bp.Neurons[targetID]
+			for i, n := 0, len(target.Connections); i < n; i++ {
+				if conn := target.Connections[i]; conn.Source != sourceID || !conn.Enabled {
+					continue
+				}
+				if relay == nil {
+					relay = bp.newRelayNeuron(sourceID)
+					inserted = append(inserted, relay.ID)
+				}
+				redirectConnection(target, i, relay.ID)
+			}
+		}
+	}
+	if bp.Debug {
+		fmt.Printf("Inserted hidden layer of %d neurons after layer %d\n", len(inserted), after)
+	}
+	return inserted
+}
+
+// AddSkipConnection connects a random neuron to one at least two inferred layers further on
+// that it does not feed yet, with a small random weight. It returns the endpoints, or -1, -1
+// when no such pair exists.
+func (bp *Phase) AddSkipConnection() (int, int) {
+	defer bp.mutationCheckpoint("AddSkipConnection", nil)()
+	return bp.addSkipConnection(nil, defaultInitScale, bp.rng())
+}
+
+// addSkipConnection adds a skip connection for which allowed, if set, returns true, with a
+// weight of standard deviation scale, drawing from rng.
+func (bp *Phase) addSkipConnection(allowed func(source, target int) bool, scale float64, rng *rand.Rand) (int, int) {
+	layerOf := make(map[int]int, len(bp.Neurons))
+	for i, layer := range bp.InferLayers() {
+		for _, id := range layer {
+			layerOf[id] = i
+		}
+	}
+
+	ids := bp.sortedNeuronIDs()
+	candidates := [][2]int{}
+	for _, source := range ids {
+		if contains(bp.OutputNodes, source) {
+			continue
+		}
+		for _, target := range ids {
+			if layerOf[target]-layerOf[source] < 2 || bp.Neurons[target].Type == "input" || bp.connectionExists(source, target) {
+				continue
+			}
+			if allowed != nil && !allowed(source, target) {
+				continue
+			}
+			candidates = append(candidates, [2]int{source, target})
+		}
+	}
+	if len(candidates) == 0 {
+		return -1, -1
+	}
+	pick := candidates[rng.Intn(len(candidates))]
+	weight := rng.NormFloat64() * scale
+	target := bp.Neurons[pick[1]]
+	target.Connections = append(target.Connections, newTrackedConnection(pick[0], pick[1], weight))
+	if target.Type == "lstm" {
+		for gate, weights := range target.GateWeights {
+			target.GateWeights[gate] = append(weights, rng.NormFloat64()*scale)
+		}
+	}
+	if bp.Debug {
+		fmt.Printf("Added skip connection from Neuron %d to Neuron %d (weight=%f)\n", pick[0], pick[1], weight)
+	}
+	return pick[0], pick[1]
+}
+
+// growthScope limits GrowWithConfig to the part of the network its checkpoints can evaluate:
+// only the checkpointed pre-output neurons and grown neurons carry real values, and only the
+// outputs and grown neurons are recomputed.
+type growthScope struct {
+	checkpointed map[int]bool
+	original     map[int]bool
+}
+
+func newGrowthScope(originalBP *Phase) *growthScope {
+	scope := &growthScope{checkpointed: make(map[int]bool), original: make(map[int]bool)}
+	for _, id := range originalBP.GetPreOutputNeurons() {
+		scope.checkpointed[id] = true
+	}
+	for id := range originalBP.Neurons {
+		scope.original[id] = true
+	}
+	return scope
+}
+
+// allows reports whether a connection from source into target stays inside the scope.
+func (s *growthScope) allows(bp *Phase, source, target int) bool {
+	return (s.checkpointed[source] || !s.original[source]) &&
+		(contains(bp.OutputNodes, target) || !s.original[target])
+}
+
+// grow applies one growth step of the given mode, drawing from rng, and returns the number of
+// neurons added.
+func (bp *Phase) grow(mode GrowthMode, cfg GrowConfig, scope *growthScope, rng *rand.Rand) int {
+	allowed := func(source, target int) bool { return scope.allows(bp, source, target) }
+	switch mode {
+	case GrowDepth:
+		defer bp.mutationCheckpoint("SplitRandomConnection", nil)()
+		relay := bp.splitRandomConnection(allowed, rng)
+		if relay == nil {
+			return 0
+		}
+		// An exact copy of the parent never improves on it, so nudge the relay off identity.
+		relay.Bias += rng.NormFloat64() * defaultInitScale
+		relay.Connections[0].Weight += rng.NormFloat64() * defaultInitScale
+		return 1
+	case GrowSkip:
+		defer bp.mutationCheckpoint("AddSkipConnection", nil)()
+		bp.addSkipConnection(allowed, defaultInitScale, rng)
+		return 0
+	default:
+		neuronType := sampleNeuronType(cfg.NeuronTypes, rng)
+		defer bp.mutationCheckpoint("AddNeuronFromPreOutputs", map[string]interface{}{"type": neuronType, "activation": "", "min_connections": cfg.MinConnections, "max_connections": cfg.MaxConnections, "scale": defaultInitScale})()
+		reverts := bp.reverts
+		newNeuron := bp.addNeuronFromPreOutputs(neuronType, "", cfg.MinConnections, cfg.MaxConnections, defaultInitScale, rng)
+		if newNeuron == nil || bp.reverts != reverts {
+			return 0 // Nothing to add, or strict mode rolled the neuron back
+		}
+		return 1
+	}
+}
+
+// sampleNeuronType draws a neuron type with probability proportional to its weight. Types are
+// visited in sorted order so seeded runs repeat; with no positive weight it returns "dense".
+func sampleNeuronType(weights map[string]float64, rng *rand.Rand) string {
+	types := make([]string, 0, len(weights))
+	total := 0.0
+	for neuronType, weight := range weights {
+		if weight > 0 {
+			types = append(types, neuronType)
+			total += weight
+		}
+	}
+	if total == 0 {
+		return "dense"
+	}
+	sort.Strings(types)
+	r := rng.Float64() * total
+	for _, neuronType := range types {
+		r -= weights[neuronType]
+		if r < 0 {
+			return neuronType
+		}
+	}
+	return types[len(types)-1]
+}
@@ -0,0 +1,478 @@
+package phase
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ImportedMLP describes a fully connected network trained in another framework.
+//
+// The dump is a JSON manifest of the form:
+//
+//	{
+//	  "framework": "keras",            // "keras" or "pytorch", selects the default weight layout
+//	  "input_size": 4,
+//	  "layers": [
+//	    {
+//	      "weights": [[...], ...],      // or "weights_file": "dense_0_kernel.npy"
+//	      "bias": [...],                // or "bias_file": "dense_0_bias.npy"
+//	      "activation": "relu",
+//	      "negative_slope": 0.01,       // leaky_relu only; see ImportedLayer.NegativeSlope
+//	      "weight_layout": "in_out"     // optional: "in_out" (Keras kernel) or "out_in" (PyTorch weight)
+//	    }
+//	  ],
+//	  "reference": {                    // optional, used by Verify
+//	    "inputs":  [[...], ...],
+//	    "outputs": [[...], ...]
+//	  }
+//	}
+//
+// NPY paths are resolved relative to the manifest. Only little-endian float32/float64/int32/int64
+// arrays are supported.
+type ImportedMLP struct {
+	Framework string             `json:"framework"`
+	InputSize int                `json:"input_size"`
+	Layers    []ImportedLayer    `json:"layers"`
+	Reference *ImportedReference `json:"reference,omitempty"`
+
+	baseDir string
+}
+
+// ImportedLayer holds the parameters of a single dense layer in an ImportedMLP.
+type ImportedLayer struct {
+	Name         string      `json:"name,omitempty"`
+	Weights      [][]float64 `json:"weights,omitempty"`
+	WeightsFile  string      `json:"weights_file,omitempty"`
+	Bias         []float64   `json:"bias,omitempty"`
+	BiasFile     string      `json:"bias_file,omitempty"`
+	Activation   string      `json:"activation"`
+	WeightLayout string      `json:"weight_layout,omitempty"`
+
+	// NegativeSlope is the slope of a leaky_relu layer for negative inputs (Keras "alpha").
+	// PHASE's leaky_relu has a fixed slope of 0.01, so any other slope is rejected. It may be
+	// omitted only for PyTorch, whose default is 0.01; Keras defaults to 0.3 and tf.nn to 0.2.
+	NegativeSlope *float64 `json:"negative_slope,omitempty"`
+}
+
+// ImportedReference holds input/output pairs recorded from the source framework.
+type ImportedReference struct {
+	Inputs  [][]float64 `json:"inputs"`
+	Outputs [][]float64 `json:"outputs"`
+}
+
+// VerificationReport summarizes how closely a built Phase reproduces the reference outputs.
+type VerificationReport struct {
+	Samples      int     `json:"samples"`
+	MaxAbsError  float64 `json:"max_abs_error"`
+	MeanAbsError float64 `json:"mean_abs_error"`
+	WorstSample  int     `json:"worst_sample"`
+}
+
+// importedActivations maps activation names used by Keras and PyTorch to PHASE activations.
+var importedActivations = map[string]string{
+	"":           "linear",
+	"linear":     "linear",
+	"identity":   "linear",
+	"none":       "linear",
+	"relu":       "relu",
+	"sigmoid":    "sigmoid",
+	"tanh":       "tanh",
+	"elu":        "elu",
+	"leaky_relu": "leaky_relu",
+	"leakyrelu":  "leaky_relu",
+	"silu":       "smooth_relu",
+	"swish":      "smooth_relu",
+	"softmax":    "softmax",
+}
+
+// LoadImportedMLP reads an MLP dump from a JSON manifest on disk.
+func LoadImportedMLP(path string) (*ImportedMLP, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model dump '%s': %w", path, err)
+	}
+	return ParseImportedMLP(data, filepath.Dir(path))
+}
+
+// ParseImportedMLP decodes an MLP dump. baseDir is used to resolve NPY file references.
+func ParseImportedMLP(data []byte, baseDir string) (*ImportedMLP, error) {
+	var m ImportedMLP
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse model dump: %w", err)
+	}
+	m.baseDir = baseDir
+	if err := m.resolve(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ImportMLP loads a dump, builds the Phase and, if the dump contains reference values,
+// verifies the Phase against them using the given absolute tolerance.
+func ImportMLP(path string, tolerance float64) (*Phase, VerificationReport, error) {
+	m, err := LoadImportedMLP(path)
+	if err != nil {
+		return nil, VerificationReport{}, err
+	}
+	bp, err := m.Build()
+	if err != nil {
+		return nil, VerificationReport{}, err
+	}
+	if m.Reference == nil {
+		return bp, VerificationReport{}, nil
+	}
+	report, err := m.Verify(bp, tolerance)
+	return bp, report, err
+}
+
+// resolve loads NPY files, normalizes every weight matrix to [out][in] and checks shapes.
+func (m *ImportedMLP) resolve() error {
+	if m.InputSize <= 0 {
+		return fmt.Errorf("model dump: input_size must be positive, got %d", m.InputSize)
+	}
+	if len(m.Layers) == 0 {
+		return fmt.Errorf("model dump: no layers")
+	}
+
+	prevSize := m.InputSize
+	for i := range m.Layers {
+		layer := &m.Layers[i]
+
+		if layer.WeightsFile != "" {
+			data, shape, err := readNPY(filepath.Join(m.baseDir, layer.WeightsFile))
+			if err != nil {
+				return fmt.Errorf("layer %d: %w", i, err)
+			}
+			if len(shape) != 2 {
+				return fmt.Errorf("layer %d: weights must be 2-D, got shape %v", i, shape)
+			}
+			layer.Weights = make([][]float64, shape[0])
+			for r := 0; r < shape[0]; r++ {
+				layer.Weights[r] = data[r*shape[1] : (r+1)*shape[1]]
+			}
+		}
+		if layer.BiasFile != "" {
+			data, shape, err := readNPY(filepath.Join(m.baseDir, layer.BiasFile))
+			if err != nil {
+				return fmt.Errorf("layer %d: %w", i, err)
+			}
+			if len(shape) != 1 {
+				return fmt.Errorf("layer %d: bias must be 1-D, got shape %v", i, shape)
+			}
+			layer.Bias = data
+		}
+		if len(layer.Weights) == 0 {
+			return fmt.Errorf("layer %d: no weights", i)
+		}
+
+		layout := layer.WeightLayout
+		if layout == "" {
+			layout = m.defaultLayout()
+		}
+		switch layout {
+		case "out_in":
+		case "in_out":
+			layer.Weights = transpose(layer.Weights)
+		default:
+			return fmt.Errorf("layer %d: unknown weight_layout '%s'", i, layout)
+		}
+		layer.WeightLayout = "out_in"
+
+		outSize := len(layer.Weights)
+		for r, row := range layer.Weights {
+			if len(row) != prevSize {
+				return fmt.Errorf("layer %d: weight row %d has %d inputs, expected %d", i, r, len(row), prevSize)
+			}
+		}
+		if layer.Bias == nil {
+			layer.Bias = make([]float64, outSize)
+		}
+		if len(layer.Bias) != outSize {
+			return fmt.Errorf("layer %d: bias has %d entries, expected %d", i, len(layer.Bias), outSize)
+		}
+
+		act, ok := importedActivations[strings.ToLower(layer.Activation)]
+		if !ok {
+			return fmt.Errorf("layer %d: unsupported activation '%s'", i, layer.Activation)
+		}
+		if act == "softmax" && i != len(m.Layers)-1 {
+			return fmt.Errorf("layer %d: softmax is only supported on the output layer", i)
+		}
+		if act == "leaky_relu" {
+			if err := m.checkLeakySlope(layer); err != nil {
+				return fmt.Errorf("layer %d: %w", i, err)
+			}
+		}
+		layer.Activation = act
+
+		prevSize = outSize
+	}
+	return nil
+}
+
+// checkLeakySlope rejects a leaky_relu layer whose slope differs from PHASE's 0.01.
+func (m *ImportedMLP) checkLeakySlope(layer *ImportedLayer) error {
+	if layer.NegativeSlope == nil {
+		if strings.EqualFold(m.Framework, "pytorch") {
+			return nil
+		}
+		return fmt.Errorf("leaky_relu needs negative_slope unless the framework is pytorch, since Keras defaults to 0.3 and PHASE uses 0.01")
+	}
+	if slope := *layer.NegativeSlope; math.Abs(slope-0.01) > 1e-9 {
+		return fmt.Errorf("leaky_relu negative_slope %g is not supported: PHASE's leaky_relu uses 0.01", slope)
+	}
+	return nil
+}
+
+// defaultLayout returns the weight layout used by the dump's source framework.
+func (m *ImportedMLP) defaultLayout() string {
+	if strings.EqualFold(m.Framework, "keras") || strings.EqualFold(m.Framework, "tensorflow") {
+		return "in_out"
+	}
+	return "out_in"
+}
+
+// Build creates a Phase with the same topology as NewPhaseWithLayers and copies
+// the imported weights, biases and activations into it.
+//
+// A softmax output layer keeps the "softmax" activation, but softmax spans the whole layer,
+// so Forward computes those neurons as linear. Call ApplySoftmax after Forward to get the
+// probabilities, as Verify does.
+func (m *ImportedMLP) Build() (*Phase, error) {
+	layers := []int{m.InputSize}
+	for _, layer := range m.Layers {
+		layers = append(layers, len(layer.Weights))
+	}
+	last := m.Layers[len(m.Layers)-1]
+	bp := NewPhaseWithLayers(layers, m.Layers[0].Activation, last.Activation)
+
+	// NewPhaseWithLayers assigns IDs layer by layer and connects every neuron to the
+	// previous layer in ID order, so connection j of neuron i maps to Weights[i][j].
+	neuronID := m.InputSize
+	for li, layer := range m.Layers {
+		for i, row := range layer.Weights {
+			neuron, exists := bp.Neurons[neuronID]
+			if !exists || len(neuron.Connections) != len(row) {
+				return nil, fmt.Errorf("layer %d: neuron %d does not match the imported shape", li, neuronID)
+			}
+			neuron.Activation = layer.Activation
+			neuron.Bias = layer.Bias[i]
+			for j, w := range row {
+				neuron.Connections[j].Weight = w
+			}
+			neuronID++
+		}
+	}
+
+	if bp.Debug {
+		fmt.Printf("Imported MLP with layers %v\n", layers)
+	}
+	return bp, nil
+}
+
+// Verify runs the reference inputs through bp and compares against the reference outputs.
+// It returns an error if the dump has no reference values or any output differs by more than tolerance.
+func (m *ImportedMLP) Verify(bp *Phase, tolerance float64) (VerificationReport, error) {
+	report := VerificationReport{WorstSample: -1}
+	if m.Reference == nil || len(m.Reference.Inputs) == 0 {
+		return report, fmt.Errorf("model dump has no reference values")
+	}
+	if len(m.Reference.Inputs) != len(m.Reference.Outputs) {
+		return report, fmt.Errorf("reference has %d inputs but %d outputs", len(m.Reference.Inputs), len(m.Reference.Outputs))
+	}
+
+	softmax := m.Layers[len(m.Layers)-1].Activation == "softmax"
+	totalErr := 0.0
+	count := 0
+	for s, input := range m.Reference.Inputs {
+		if len(input) != len(bp.InputNodes) {
+			return report, fmt.Errorf("reference sample %d has %d inputs, expected %d", s, len(input), len(bp.InputNodes))
+		}
+		expected := m.Reference.Outputs[s]
+		if len(expected) != len(bp.OutputNodes) {
+			return report, fmt.Errorf("reference sample %d has %d outputs, expected %d", s, len(expected), len(bp.OutputNodes))
+		}
+
+		inputs := make(map[int]float64, len(input))
+		for i, id := range bp.InputNodes {
+			inputs[id] = input[i]
+		}
+		bp.Forward(inputs, 1)
+		if softmax {
+			bp.ApplySoftmax()
+		}
+
+		for i, id := range bp.OutputNodes {
+			diff := math.Abs(bp.Neurons[id].Value - expected[i])
+			totalErr += diff
+			count++
+			if diff > report.MaxAbsError || report.WorstSample == -1 {
+				report.MaxAbsError = diff
+				report.WorstSample = s
+			}
+		}
+	}
+
+	report.Samples = len(m.Reference.Inputs)
+	if count > 0 {
+		report.MeanAbsError = totalErr / float64(count)
+	}
+	if report.MaxAbsError > tolerance {
+		return report, fmt.Errorf("imported model deviates from reference: max abs error %g at sample %d exceeds tolerance %g",
+			report.MaxAbsError, report.WorstSample, tolerance)
+	}
+	return report, nil
+}
+
+// transpose returns the transpose of a rectangular matrix.
+func transpose(m [][]float64) [][]float64 {
+	if len(m) == 0 {
+		return m
+	}
+	t := make([][]float64, len(m[0]))
+	for j := range t {
+		t[j] = make([]float64, len(m))
+		for i := range m {
+			t[j][i] = m[i][j]
+		}
+	}
+	return t
+}
+
+// readNPY reads a little-endian numeric NPY array and returns its values in C order with its shape.
+func readNPY(path string) ([]float64, []int, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read NPY file '%s': %w", path, err)
+	}
+	if len(raw) < 10 || string(raw[:6]) != "\x93NUMPY" {
+		return nil, nil, fmt.Errorf("'%s' is not an NPY file", path)
+	}
+
+	major := raw[6]
+	var headerLen, offset int
+	switch major {
+	case 1:
+		headerLen = int(binary.LittleEndian.Uint16(raw[8:10]))
+		offset = 10
+	case 2, 3:
+		if len(raw) < 12 {
+			return nil, nil, fmt.Errorf("'%s': truncated NPY header", path)
+		}
+		headerLen = int(binary.LittleEndian.Uint32(raw[8:12]))
+		offset = 12
+	default:
+		return nil, nil, fmt.Errorf("'%s': unsupported NPY version %d", path, major)
+	}
+	if len(raw) < offset+headerLen {
+		return nil, nil, fmt.Errorf("'%s': truncated NPY header", path)
+	}
+	header := string(raw[offset : offset+headerLen])
+	body := raw[offset+headerLen:]
+
+	descr, err := npyHeaderString(header, "descr")
+	if err != nil {
+		return nil, nil, fmt.Errorf("'%s': %w", path, err)
+	}
+	fortran := strings.Contains(header, "'fortran_order': True")
+	shape, err := npyHeaderShape(header)
+	if err != nil {
+		return nil, nil, fmt.Errorf("'%s': %w", path, err)
+	}
+
+	count := 1
+	for _, d := range shape {
+		count *= d
+	}
+
+	var width int
+	switch descr {
+	case "<f4", "<i4":
+		width = 4
+	case "<f8", "<i8":
+		width = 8
+	default:
+		return nil, nil, fmt.Errorf("'%s': unsupported dtype '%s'", path, descr)
+	}
+	if len(body) < count*width {
+		return nil, nil, fmt.Errorf("'%s': expected %d values, file is truncated", path, count)
+	}
+
+	data := make([]float64, count)
+	for i := range data {
+		chunk := body[i*width : (i+1)*width]
+		switch descr {
+		case "<f4":
+			data[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(chunk)))
+		case "<f8":
+			data[i] = math.Float64frombits(binary.LittleEndian.Uint64(chunk))
+		case "<i4":
+			data[i] = float64(int32(binary.LittleEndian.Uint32(chunk)))
+		case "<i8":
+			data[i] = float64(int64(binary.LittleEndian.Uint64(chunk)))
+		}
+	}
+
+	if fortran && len(shape) == 2 {
+		rows, cols := shape[0], shape[1]
+		reordered := make([]float64, count)
+		for r := 0; r < rows; r++ {
+			for c := 0; c < cols; c++ {
+				reordered[r*cols+c] = data[c*rows+r]
+			}
+		}
+		data = reordered
+	}
+	return data, shape, nil
+}
+
+// npyHeaderString extracts a quoted string value from an NPY header dictionary.
+func npyHeaderString(header, key string) (string, error) {
+	idx := strings.Index(header, "'"+key+"'")
+	if idx < 0 {
+		return "", fmt.Errorf("NPY header missing '%s'", key)
+	}
+	rest := header[idx+len(key)+2:]
+	start := strings.Index(rest, "'")
+	if start < 0 {
+		return "", fmt.Errorf("malformed NPY header value for '%s'", key)
+	}
+	end := strings.Index(rest[start+1:], "'")
+	if end < 0 {
+		return "", fmt.Errorf("malformed NPY header value for '%s'", key)
+	}
+	return rest[start+1 : start+1+end], nil
+}
+
+// npyHeaderShape extracts the shape tuple from an NPY header dictionary.
+func npyHeaderShape(header string) ([]int, error) {
+	idx := strings.Index(header, "'shape'")
+	if idx < 0 {
+		return nil, fmt.Errorf("NPY header missing 'shape'")
+	}
+	rest := header[idx:]
+	open := strings.Index(rest, "(")
+	closing := strings.Index(rest, ")")
+	if open < 0 || closing < open {
+		return nil, fmt.Errorf("malformed NPY shape")
+	}
+	shape := []int{}
+	for _, part := range strings.Split(rest[open+1:closing], ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("malformed NPY shape: %w", err)
+		}
+		shape = append(shape, d)
+	}
+	return shape, nil
+}
@@ -0,0 +1,166 @@
+package phase
+
+// ImprovementPolicy decides how much better a candidate model is than the current one.
+// Grow (GrowConfig.Policy), TournamentSelectionWithPolicy, SelectBestModelWithPolicy, the
+// WithPolicy variants of the new-neuron optimizers and OptimizeBlackBox (BlackBoxConfig.Policy)
+// take a policy explicitly. A nil policy, and the variants without one, fall back to the policy
+// set on the receiving Phase (see Phase.ImprovementPolicy), which is not saved with the model.
+type ImprovementPolicy interface {
+	// Improvement scores result against the current metrics; positive means better.
+	Improvement(result ModelResult, currentExactAcc, currentClosenessQuality, currentApproxScore float64) float64
+	// ClosenessQuality condenses closeness bins into a single quality value.
+	ClosenessQuality(bins []float64) float64
+}
+
+// defaultClosenessWeights weigh the closeness bins: lower bins (closer predictions) count more.
+var defaultClosenessWeights = []float64{1.0, 0.9, 0.8, 0.7, 0.6, 0.5, 0.4, 0.3, 0.2, 0.1}
+
+// closenessQuality sums the weighted bins, excluding the >90% bin.
+func closenessQuality(bins, weights []float64) float64 {
+	if weights == nil {
+		weights = defaultClosenessWeights
+	}
+	quality := 0.0
+	for i := 0; i < len(bins)-1 && i < len(weights); i++ {
+		quality += bins[i] * weights[i]
+	}
+	return quality
+}
+
+// WeightedImprovement blends the normalized metric deltas with fixed weights.
+type WeightedImprovement struct {
+	ExactAcc         float64
+	Closeness        float64
+	ApproxScore      float64
+	ClosenessWeights []float64 // Per-bin weights for ClosenessQuality; nil uses the defaults
+}
+
+// DefaultImprovementPolicy returns the original blend: 0.2 exact accuracy, 0.3 closeness
+// quality and 0.5 approx score.
+func DefaultImprovementPolicy() ImprovementPolicy {
+	return WeightedImprovement{ExactAcc: 0.2, Closeness: 0.3, ApproxScore: 0.5}
+}
+
+// Improvement returns the weighted sum of the metric deltas, each divided by 100.
+func (w WeightedImprovement) Improvement(result ModelResult, currentExactAcc, currentClosenessQuality, currentApproxScore float64) float64 {
+	deltaExactAcc := (result.ExactAcc - currentExactAcc) / 100.0
+	deltaCloseness := (w.ClosenessQuality(result.ClosenessBins) - currentClosenessQuality) / 100.0
+	deltaApproxScore := (result.ApproxScore - currentApproxScore) / 100.0
+	return w.ExactAcc*deltaExactAcc + w.Closeness*deltaCloseness + w.ApproxScore*deltaApproxScore
+}
+
+// ClosenessQuality sums the bins weighted by ClosenessWeights.
+func (w WeightedImprovement) ClosenessQuality(bins []float64) float64 {
+	return closenessQuality(bins, w.ClosenessWeights)
+}
+
+// Metric names used by LexicographicImprovement.
+const (
+	MetricExactAcc    = "exact_acc"
+	MetricCloseness   = "closeness"
+	MetricApproxScore = "approx_score"
+)
+
+// LexicographicImprovement compares metrics one at a time in priority order. The first metric
+// whose delta exceeds Epsilon decides, and its normalized delta is the improvement.
+type LexicographicImprovement struct {
+	Order            []string // Metric names, highest priority first; defaults to exact_acc, closeness, approx_score
+	Epsilon          float64  // Deltas at or below this many points count as ties
+	ClosenessWeights []float64
+}
+
+// Improvement returns the normalized delta of the first metric that is not tied.
+func (l LexicographicImprovement) Improvement(result ModelResult, currentExactAcc, currentClosenessQuality, currentApproxScore float64) float64 {
+	order := l.Order
+	if len(order) == 0 {
+		order = []string{MetricExactAcc, MetricCloseness, MetricApproxScore}
+	}
+	for _, metric := range order {
+		var delta float64
+		switch metric {
+		case MetricExactAcc:
+			delta = result.ExactAcc - currentExactAcc
+		case MetricCloseness:
+			delta = l.ClosenessQuality(result.ClosenessBins) - currentClosenessQuality
+		case MetricApproxScore:
+			delta = result.ApproxScore - currentApproxScore
+		default:
+			continue
+		}
+		if delta > l.Epsilon || delta < -l.Epsilon {
+			return delta / 100.0
+		}
+	}
+	return 0
+}
+
+// ClosenessQuality sums the bins weighted by ClosenessWeights.
+func (l LexicographicImprovement) ClosenessQuality(bins []float64) float64 {
+	return closenessQuality(bins, l.ClosenessWeights)
+}
+
+// ThresholdedImprovement wraps another policy with guard rails. A candidate whose metrics drop
+// by more than the allowed number of points scores -1, and improvements below MinGain count as 0.
+type ThresholdedImprovement struct {
+	Base               ImprovementPolicy // Defaults to DefaultImprovementPolicy()
+	MinGain            float64
+	MaxExactAccDrop    float64
+	MaxClosenessDrop   float64
+	MaxApproxScoreDrop float64
+}
+
+// Improvement applies the drop limits and the minimum gain to the base improvement.
+func (t ThresholdedImprovement) Improvement(result ModelResult, currentExactAcc, currentClosenessQuality, currentApproxScore float64) float64 {
+	if currentExactAcc-result.ExactAcc > t.MaxExactAccDrop ||
+		currentClosenessQuality-t.ClosenessQuality(result.ClosenessBins) > t.MaxClosenessDrop ||
+		currentApproxScore-result.ApproxScore > t.MaxApproxScoreDrop {
+		return -1
+	}
+	improvement := t.base().Improvement(result, currentExactAcc, currentClosenessQuality, currentApproxScore)
+	if improvement > 0 && improvement < t.MinGain {
+		return 0
+	}
+	return improvement
+}
+
+// ClosenessQuality delegates to the base policy.
+func (t ThresholdedImprovement) ClosenessQuality(bins []float64) float64 {
+	return t.base().ClosenessQuality(bins)
+}
+
+func (t ThresholdedImprovement) base() ImprovementPolicy {
+	if t.Base == nil {
+		return DefaultImprovementPolicy()
+	}
+	return t.Base
+}
+
+// ImprovementFunc adapts a plain scoring function to ImprovementPolicy.
+// Closeness quality uses the default bin weights.
+type ImprovementFunc func(result ModelResult, currentExactAcc, currentClosenessQuality, currentApproxScore float64) float64
+
+// Improvement calls f.
+func (f ImprovementFunc) Improvement(result ModelResult, currentExactAcc, currentClosenessQuality, currentApproxScore float64) float64 {
+	return f(result, currentExactAcc, currentClosenessQuality, currentApproxScore)
+}
+
+// ClosenessQuality uses the default bin weights.
+func (f ImprovementFunc) ClosenessQuality(bins []float64) float64 {
+	return closenessQuality(bins, nil)
+}
+
+// resolvePolicy returns policy, or the Phase's own policy when policy is nil.
+func (bp *Phase) resolvePolicy(policy ImprovementPolicy) ImprovementPolicy {
+	if policy == nil {
+		return bp.improvementPolicy()
+	}
+	return policy
+}
+
+// improvementPolicy returns the Phase's policy, or the default blend when none is set.
+func (bp *Phase) improvementPolicy() ImprovementPolicy {
+	if bp.ImprovementPolicy == nil {
+		return DefaultImprovementPolicy()
+	}
+	return bp.ImprovementPolicy
+}
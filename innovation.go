@@ -0,0 +1,161 @@
+package phase
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// InnovationTracker hands out NEAT-style historical markings.
+// A connection between the same source and target always gets the same innovation number, so
+// genes created independently in different individuals line up during crossover. Node IDs are
+// unique across every Phase that draws from the tracker.
+type InnovationTracker struct {
+	mu             sync.Mutex
+	nextInnovation int
+	nextNodeID     int
+	connections    map[[2]int]int // (source, target) -> innovation
+}
+
+// Innovations is the tracker used by the mutation operators and Crossover.
+var Innovations = NewInnovationTracker()
+
+// NewInnovationTracker returns an empty tracker. Innovation numbers start at 1.
+func NewInnovationTracker() *InnovationTracker {
+	return &InnovationTracker{
+		nextInnovation: 1,
+		connections:    make(map[[2]int]int),
+	}
+}
+
+// Connection returns the innovation number of the connection from source to target,
+// assigning a new one the first time the pair is seen.
+func (t *InnovationTracker) Connection(source, target int) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := [2]int{source, target}
+	if innovation, exists := t.connections[key]; exists {
+		return innovation
+	}
+	innovation := t.nextInnovation
+	t.nextInnovation++
+	t.connections[key] = innovation
+	return innovation
+}
+
+// lookup returns the innovation number of the connection from source to target without
+// assigning one. When the pair has never been seen, ok is false and innovation is the number
+// the pair would get next.
+func (t *InnovationTracker) lookup(source, target int) (innovation int, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if innovation, exists := t.connections[[2]int{source, target}]; exists {
+		return innovation, true
+	}
+	return t.nextInnovation, false
+}
+
+// register makes innovation the number of the connection from source to target, replacing
+// the number the pair had before.
+func (t *InnovationTracker) register(source, target, innovation int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.connections[[2]int{source, target}] = innovation
+	if innovation >= t.nextInnovation {
+		t.nextInnovation = innovation + 1
+	}
+}
+
+// NodeID returns a new node ID that is at least floor and has never been handed out before.
+// Pass bp.GetNextNeuronID() as floor so the ID is also free in the Phase.
+func (t *InnovationTracker) NodeID(floor int) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	id := t.nextNodeID
+	if floor > id {
+		id = floor
+	}
+	t.nextNodeID = id + 1
+	return id
+}
+
+// Reset forgets every innovation and node ID handed out so far.
+func (t *InnovationTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nextInnovation = 1
+	t.nextNodeID = 0
+	t.connections = make(map[[2]int]int)
+}
+
+// newTrackedConnection returns an enabled connection carrying its innovation number.
+func newTrackedConnection(source, target int, weight float64) Connection {
+	conn := NewConnection(source, weight)
+	conn.Innovation = Innovations.Connection(source, target)
+	return conn
+}
+
+// AssignInnovations gives every connection without an innovation number its marking from
+// the global tracker, neuron by neuron in ID order so the numbering is reproducible. Networks
+// built by NewPhaseWithLayers or loaded from old files have none.
+func (bp *Phase) AssignInnovations() {
+	for _, id := range bp.sortedNeuronIDs() {
+		neuron := bp.Neurons[id]
+		for i := range neuron.Connections {
+			if neuron.Connections[i].Innovation == 0 {
+				neuron.Connections[i].Innovation = Innovations.Connection(neuron.Connections[i].Source, id)
+			}
+		}
+	}
+}
+
+// connectionInnovation returns the innovation number of a connection into target,
+// looking it up in the tracker when the connection has none.
+func connectionInnovation(conn Connection, target int) int {
+	if conn.Innovation != 0 {
+		return conn.Innovation
+	}
+	return Innovations.Connection(conn.Source, target)
+}
+
+// innovationTrackerJSON is the serialized form of an InnovationTracker.
+type innovationTrackerJSON struct {
+	NextInnovation int      `json:"next_innovation"`
+	NextNodeID     int      `json:"next_node_id"`
+	Connections    [][3]int `json:"connections"` // [source, target, innovation], by innovation
+}
+
+// MarshalJSON serializes every innovation and node ID handed out so far, so a long run can
+// save the tracker and carry on with the same numbering later.
+func (t *InnovationTracker) MarshalJSON() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state := innovationTrackerJSON{
+		NextInnovation: t.nextInnovation,
+		NextNodeID:     t.nextNodeID,
+		Connections:    make([][3]int, 0, len(t.connections)),
+	}
+	for key, innovation := range t.connections {
+		state.Connections = append(state.Connections, [3]int{key[0], key[1], innovation})
+	}
+	sort.Slice(state.Connections, func(i, j int) bool { return state.Connections[i][2] < state.Connections[j][2] })
+	return json.Marshal(state)
+}
+
+// UnmarshalJSON replaces the tracker's state with one written by MarshalJSON.
+func (t *InnovationTracker) UnmarshalJSON(data []byte) error {
+	var state innovationTrackerJSON
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse innovation tracker: %v", err)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nextInnovation = state.NextInnovation
+	t.nextNodeID = state.NextNodeID
+	t.connections = make(map[[2]int]int, len(state.Connections))
+	for _, conn := range state.Connections {
+		t.connections[[2]int{conn[0], conn[1]}] = conn[2]
+	}
+	return nil
+}
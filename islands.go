@@ -0,0 +1,245 @@
+package phase
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// MigrationPolicy decides which individuals move between islands.
+type MigrationPolicy interface {
+	// Plan returns, for each destination island, the individuals it receives.
+	// Islands are evaluated when Plan is called; count is IslandConfig.Migrants.
+	Plan(islands []*Population, count int, rng *rand.Rand) [][]*Individual
+}
+
+// RingMigration sends the best individuals of island i to island i+1, wrapping around.
+type RingMigration struct{}
+
+// Plan moves each island's best count individuals one step around the ring.
+func (RingMigration) Plan(islands []*Population, count int, rng *rand.Rand) [][]*Individual {
+	plan := make([][]*Individual, len(islands))
+	for i, island := range islands {
+		plan[(i+1)%len(islands)] = bestIndividuals(island.Individuals, count)
+	}
+	return plan
+}
+
+// RandomMigration sends the best individuals of each island to another island drawn at random.
+type RandomMigration struct{}
+
+// Plan moves each island's best count individuals to a random other island.
+func (RandomMigration) Plan(islands []*Population, count int, rng *rand.Rand) [][]*Individual {
+	plan := make([][]*Individual, len(islands))
+	for i, island := range islands {
+		dest := rng.Intn(len(islands) - 1)
+		if dest >= i {
+			dest++
+		}
+		plan[dest] = append(plan[dest], bestIndividuals(island.Individuals, count)...)
+	}
+	return plan
+}
+
+// BestKMigration broadcasts the K best individuals across all islands to every island
+// that does not already hold them. K defaults to the migrant count.
+type BestKMigration struct {
+	K int
+}
+
+// Plan sends the global top K to every other island.
+func (b BestKMigration) Plan(islands []*Population, count int, rng *rand.Rand) [][]*Individual {
+	k := b.K
+	if k < 1 {
+		k = count
+	}
+	type ranked struct {
+		ind    *Individual
+		island int
+	}
+	all := []ranked{}
+	for i, island := range islands {
+		for _, ind := range island.Individuals {
+			all = append(all, ranked{ind, i})
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].ind.Fitness > all[j].ind.Fitness })
+	if k > len(all) {
+		k = len(all)
+	}
+
+	plan := make([][]*Individual, len(islands))
+	for _, r := range all[:k] {
+		for dest := range islands {
+			if dest != r.island {
+				plan[dest] = append(plan[dest], r.ind)
+			}
+		}
+	}
+	return plan
+}
+
+// bestIndividuals returns the n fittest of individuals, fittest first.
+func bestIndividuals(individuals []*Individual, n int) []*Individual {
+	sorted := append([]*Individual{}, individuals...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Fitness > sorted[j].Fitness })
+	if n > len(sorted) {
+		n = len(sorted)
+	}
+	return sorted[:n]
+}
+
+// IslandConfig configures an IslandModel.
+type IslandConfig struct {
+	Islands    int              // Number of independent populations; at least 2
+	Population PopulationConfig // Configuration of every island; Seed is replaced per island
+	Interval   int              // Generations between migrations; defaults to 5
+	Migrants   int              // Individuals each island sends per migration; defaults to 1
+	Policy     MigrationPolicy  // Defaults to RingMigration{}
+	Seed       int64            // Seeds the islands and the migration policy
+}
+
+// IslandStats summarizes one generation of every island.
+type IslandStats struct {
+	Generation int
+	Islands    []GenerationStats // Per-island statistics, in island order
+	Best       *Individual       // Fittest individual across all islands in this generation
+	Migrated   int               // Individuals moved after this generation's evaluation; 0 between migrations
+}
+
+// IslandModel runs several Populations side by side and periodically migrates individuals
+// between them. Island i is seeded with Seed+i.
+//
+// Fitness evaluation, the expensive part, runs on one goroutine per island. Selection,
+// breeding and migration then run island by island in a fixed order, so every seeded RNG is
+// drawn from in the same sequence on every run.
+type IslandModel struct {
+	Config     IslandConfig
+	Islands    []*Population
+	Generation int         // Number of completed generations
+	Best       *Individual // Fittest individual seen so far on any island
+
+	hooks []func(IslandStats)
+	rng   *rand.Rand
+}
+
+// NewIslandModel creates cfg.Islands populations, each started from seed.
+func NewIslandModel(seed *Phase, cfg IslandConfig) (*IslandModel, error) {
+	if cfg.Islands < 2 {
+		return nil, fmt.Errorf("islands: need at least 2 islands, got %d", cfg.Islands)
+	}
+	if cfg.Interval < 1 {
+		cfg.Interval = 5
+	}
+	if cfg.Migrants < 1 {
+		cfg.Migrants = 1
+	}
+	if cfg.Policy == nil {
+		cfg.Policy = RingMigration{}
+	}
+	if cfg.Population.Workers < 1 {
+		// Share the cores between islands evaluating at the same time.
+		cfg.Population.Workers = runtime.NumCPU() / cfg.Islands
+		if cfg.Population.Workers < 1 {
+			cfg.Population.Workers = 1
+		}
+	}
+
+	m := &IslandModel{
+		Config: cfg,
+		rng:    rand.New(rand.NewSource(cfg.Seed)),
+	}
+	for i := 0; i < cfg.Islands; i++ {
+		popCfg := cfg.Population
+		popCfg.Seed = cfg.Seed + int64(i)
+		if cfg.Population.MutationConfig != nil {
+			mutation := *cfg.Population.MutationConfig // Each island adapts its own step size
+			popCfg.MutationConfig = &mutation
+		}
+		island, err := NewPopulation(seed, popCfg)
+		if err != nil {
+			return nil, fmt.Errorf("islands: island %d: %w", i, err)
+		}
+		m.Islands = append(m.Islands, island)
+	}
+	return m, nil
+}
+
+// OnGeneration registers a hook called with the statistics of every generation.
+func (m *IslandModel) OnGeneration(hook func(IslandStats)) {
+	m.hooks = append(m.hooks, hook)
+}
+
+// Step evaluates every island concurrently, migrates when the interval is reached, then
+// advances each island by one generation.
+func (m *IslandModel) Step() IslandStats {
+	var wg sync.WaitGroup
+	for _, island := range m.Islands {
+		wg.Add(1)
+		go func(p *Population) {
+			defer wg.Done()
+			p.Evaluate()
+		}(island)
+	}
+	wg.Wait()
+
+	migrated := 0
+	if (m.Generation+1)%m.Config.Interval == 0 {
+		migrated = m.migrate()
+	}
+
+	stats := IslandStats{Generation: m.Generation, Migrated: migrated}
+	for _, island := range m.Islands {
+		islandStats := island.Step()
+		stats.Islands = append(stats.Islands, islandStats)
+		if stats.Best == nil || islandStats.Best.Fitness > stats.Best.Fitness {
+			stats.Best = islandStats.Best
+		}
+	}
+	if m.Best == nil || stats.Best.Fitness > m.Best.Fitness {
+		m.Best = stats.Best
+	}
+	for _, hook := range m.hooks {
+		hook(stats)
+	}
+	m.Generation++
+	return stats
+}
+
+// Run calls Step for the given number of generations, stopping early when ctx is done.
+// It returns the statistics of the last completed generation.
+func (m *IslandModel) Run(ctx context.Context, generations int) (IslandStats, error) {
+	var stats IslandStats
+	for g := 0; g < generations; g++ {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		stats = m.Step()
+	}
+	return stats, nil
+}
+
+// migrate applies the policy: each island's least fit individuals are replaced by copies of
+// the migrants it receives. Migrants keep their fitness. It returns the number moved.
+func (m *IslandModel) migrate() int {
+	plan := m.Config.Policy.Plan(m.Islands, m.Config.Migrants, m.rng)
+	moved := 0
+	for dest, migrants := range plan {
+		island := m.Islands[dest]
+		sort.SliceStable(island.Individuals, func(i, j int) bool {
+			return island.Individuals[i].Fitness > island.Individuals[j].Fitness
+		})
+		for i, migrant := range migrants {
+			slot := len(island.Individuals) - 1 - i
+			if slot < 0 {
+				break
+			}
+			island.Individuals[slot] = &Individual{BP: migrant.BP.Copy(), Fitness: migrant.Fitness, Evaluated: true}
+			moved++
+		}
+	}
+	return moved
+}
@@ -0,0 +1,367 @@
+package phase
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LineageEvent records one step in the history of a model: the operator that produced Child
+// from Parents, its parameters, and the metric changes it caused when they are known.
+type LineageEvent struct {
+	ID        int                    `json:"id"`
+	Operator  string                 `json:"operator"` // e.g. "AddConnection", "Crossover", "Grow", "TrainNetwork"
+	Params    map[string]interface{} `json:"params,omitempty"`
+	Parents   []string               `json:"parents"` // Model IDs
+	Child     string                 `json:"child"`   // Model ID
+	Deltas    map[string]float64     `json:"deltas,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// Genealogy stores the lineage events of every model that shares it. Set Phase.Genealogy to
+// start recording; Copy and Crossover pass the store on to their results. It is safe for
+// concurrent use.
+//
+// Models are identified by ModelMetadata.ModelID. A mutation applied in place gives the Phase a
+// new model ID whose parent is the previous one, so the events form a DAG of model versions.
+type Genealogy struct {
+	mu        sync.Mutex
+	events    []LineageEvent
+	producers map[string][]int // Model ID -> indices of events whose Child is that model
+	nextModel int
+}
+
+// NewGenealogy returns an empty store.
+func NewGenealogy() *Genealogy {
+	return &Genealogy{producers: make(map[string][]int)}
+}
+
+// NewModelID returns a model ID that has not been handed out by this store before.
+func (g *Genealogy) NewModelID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.newModelIDLocked()
+}
+
+// newModelIDLocked is NewModelID for callers holding g.mu.
+func (g *Genealogy) newModelIDLocked() string {
+	g.nextModel++
+	return fmt.Sprintf("model-%d", g.nextModel)
+}
+
+// Record appends an event, assigning its ID and timestamp, and returns it.
+func (g *Genealogy) Record(event LineageEvent) LineageEvent {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	event.ID = len(g.events) + 1
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	g.producers[event.Child] = append(g.producers[event.Child], len(g.events))
+	g.events = append(g.events, event)
+	return event
+}
+
+// Events returns every recorded event in recording order.
+func (g *Genealogy) Events() []LineageEvent {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]LineageEvent{}, g.events...)
+}
+
+// Len returns the number of recorded events.
+func (g *Genealogy) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.events)
+}
+
+// Producers returns the events that produced modelID.
+func (g *Genealogy) Producers(modelID string) []LineageEvent {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	events := make([]LineageEvent, 0, len(g.producers[modelID]))
+	for _, idx := range g.producers[modelID] {
+		events = append(events, g.events[idx])
+	}
+	return events
+}
+
+// Children returns the models produced directly from modelID, in recording order.
+func (g *Genealogy) Children(modelID string) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	children := []string{}
+	seen := make(map[string]bool)
+	for _, event := range g.events {
+		for _, parent := range event.Parents {
+			if parent == modelID && !seen[event.Child] {
+				seen[event.Child] = true
+				children = append(children, event.Child)
+			}
+		}
+	}
+	return children
+}
+
+// Ancestry returns every event that contributed to modelID, oldest first.
+func (g *Genealogy) Ancestry(modelID string) []LineageEvent {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	visitedModels := map[string]bool{modelID: true}
+	visitedEvents := make(map[int]bool)
+	queue := []string{modelID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, idx := range g.producers[current] {
+			if visitedEvents[idx] {
+				continue
+			}
+			visitedEvents[idx] = true
+			for _, parent := range g.events[idx].Parents {
+				if !visitedModels[parent] {
+					visitedModels[parent] = true
+					queue = append(queue, parent)
+				}
+			}
+		}
+	}
+
+	indices := make([]int, 0, len(visitedEvents))
+	for idx := range visitedEvents {
+		indices = append(indices, idx)
+	}
+	sort.Ints(indices)
+	events := make([]LineageEvent, len(indices))
+	for i, idx := range indices {
+		events[i] = g.events[idx]
+	}
+	return events
+}
+
+// OperatorStats counts the operators in the ancestry of modelID, answering which mutations
+// produced it.
+func (g *Genealogy) OperatorStats(modelID string) map[string]int {
+	counts := make(map[string]int)
+	for _, event := range g.Ancestry(modelID) {
+		counts[event.Operator]++
+	}
+	return counts
+}
+
+// ExportDOT renders the genealogy as a Graphviz digraph: one node per model and one edge per
+// parent of every event, labeled with the operator. A non-empty modelID restricts the graph to
+// that model's ancestry.
+func (g *Genealogy) ExportDOT(modelID string) string {
+	events := g.Events()
+	if modelID != "" {
+		events = g.Ancestry(modelID)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("digraph Genealogy {\n")
+	sb.WriteString("  rankdir=TB;\n")
+	sb.WriteString("  node [shape=box, style=rounded];\n")
+
+	models := []string{}
+	seen := make(map[string]bool)
+	addModel := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			models = append(models, id)
+		}
+	}
+	for _, event := range events {
+		for _, parent := range event.Parents {
+			addModel(parent)
+		}
+		addModel(event.Child)
+	}
+	for _, id := range models {
+		if id == modelID {
+			fmt.Fprintf(&sb, "  %q [penwidth=2];\n", id)
+		} else {
+			fmt.Fprintf(&sb, "  %q;\n", id)
+		}
+	}
+	for _, event := range events {
+		for _, parent := range event.Parents {
+			fmt.Fprintf(&sb, "  %q -> %q [label=%q];\n", parent, event.Child, event.Operator)
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// ExportJSON serializes every event as a JSON array.
+func (g *Genealogy) ExportJSON() ([]byte, error) {
+	return json.MarshalIndent(g.Events(), "", "  ")
+}
+
+// SaveJSON writes the events to a JSON file.
+func (g *Genealogy) SaveJSON(fileName string) error {
+	data, err := g.ExportJSON()
+	if err != nil {
+		return fmt.Errorf("failed to serialize genealogy: %v", err)
+	}
+	if err := os.WriteFile(fileName, data, 0644); err != nil {
+		return fmt.Errorf("failed to write genealogy to '%s': %v", fileName, err)
+	}
+	return nil
+}
+
+// LoadGenealogy reads events written by SaveJSON. New model IDs continue after the highest
+// "model-N" ID found in the file.
+func LoadGenealogy(fileName string) (*Genealogy, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read genealogy '%s': %v", fileName, err)
+	}
+	var events []LineageEvent
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("failed to parse genealogy '%s': %v", fileName, err)
+	}
+	g := NewGenealogy()
+	noteModel := func(id string) {
+		var n int
+		if _, err := fmt.Sscanf(id, "model-%d", &n); err == nil && n > g.nextModel {
+			g.nextModel = n
+		}
+	}
+	for _, event := range events {
+		g.producers[event.Child] = append(g.producers[event.Child], len(g.events))
+		g.events = append(g.events, event)
+		noteModel(event.Child)
+		for _, parent := range event.Parents {
+			noteModel(parent)
+		}
+	}
+	return g, nil
+}
+
+// ensureModelID gives bp a model ID from g if it has none yet and returns it. It runs under
+// g's mutex, so goroutines copying the same Phase agree on the ID without racing.
+func (bp *Phase) ensureModelID(g *Genealogy) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if bp.Metadata == nil {
+		bp.Metadata = &ModelMetadata{}
+	}
+	if bp.Metadata.ModelID == "" {
+		bp.Metadata.ModelID = g.newModelIDLocked()
+		bp.Metadata.CreationTimestamp = time.Now().Format(time.RFC3339)
+	}
+	return bp.Metadata.ModelID
+}
+
+// recordLineage records that bp became a new model through operator. Without extra parents the
+// operator was applied to bp in place and bp's previous model is the parent; otherwise bp is a
+// fresh offspring of parents. bp receives a new model ID either way.
+func (bp *Phase) recordLineage(operator string, params map[string]interface{}, deltas map[string]float64, parents ...*Phase) {
+	g := bp.Genealogy
+	if g == nil {
+		return
+	}
+	parentIDs := []string{}
+	if len(parents) == 0 {
+		parentIDs = append(parentIDs, bp.ensureModelID(g))
+	} else {
+		for _, parent := range parents {
+			parentIDs = append(parentIDs, parent.ensureModelID(g))
+		}
+	}
+
+	childID := g.NewModelID()
+	now := time.Now()
+	if bp.Metadata == nil {
+		bp.Metadata = &ModelMetadata{}
+	}
+	bp.Metadata.ModelID = childID
+	bp.Metadata.ParentModelIDs = parentIDs
+	bp.Metadata.ChildModelIDs = nil
+	bp.Metadata.CreationTimestamp = now.Format(time.RFC3339)
+	bp.Metadata.LastModified = now.Format(time.RFC3339)
+	for _, parent := range parents {
+		parent.Metadata.ChildModelIDs = append(parent.Metadata.ChildModelIDs, childID)
+	}
+
+	g.Record(LineageEvent{
+		Operator:  operator,
+		Params:    params,
+		Parents:   parentIDs,
+		Child:     childID,
+		Deltas:    deltas,
+		Timestamp: now,
+	})
+}
+
+// recordAcceptance records that bp, a candidate derived from parent, was accepted by operator
+// with the given metric deltas. bp keeps its model ID; the event links it directly to parent.
+func (bp *Phase) recordAcceptance(operator string, parent *Phase, params map[string]interface{}, deltas map[string]float64) {
+	g := bp.Genealogy
+	if g == nil {
+		return
+	}
+	g.Record(LineageEvent{
+		Operator: operator,
+		Params:   params,
+		Parents:  []string{parent.ensureModelID(g)},
+		Child:    bp.ensureModelID(g),
+		Deltas:   deltas,
+	})
+}
+
+// recordTraining records a training call on bp. Consecutive calls of the same operator on the
+// same model are folded into one event whose "steps" parameter counts them, so per-sample
+// training does not flood the store.
+func (bp *Phase) recordTraining(operator string, learningRate float64) {
+	g := bp.Genealogy
+	if g == nil {
+		return
+	}
+	if bp.Metadata != nil && bp.Metadata.ModelID != "" {
+		g.mu.Lock()
+		if producers := g.producers[bp.Metadata.ModelID]; len(producers) > 0 {
+			last := &g.events[producers[len(producers)-1]]
+			if last.Operator == operator && last.Params["learning_rate"] == learningRate {
+				last.Params["steps"] = lineageSteps(last.Params["steps"]) + 1
+				g.mu.Unlock()
+				bp.Metadata.LastModified = time.Now().Format(time.RFC3339)
+				return
+			}
+		}
+		g.mu.Unlock()
+	}
+	bp.recordLineage(operator, map[string]interface{}{"learning_rate": learningRate, "steps": 1}, nil)
+}
+
+// lineageSteps reads a "steps" parameter, which is a float64 once loaded from JSON.
+func lineageSteps(v interface{}) int {
+	switch steps := v.(type) {
+	case int:
+		return steps
+	case float64:
+		return int(steps)
+	}
+	return 0
+}
+
+// mutationCheckpoint wraps an exported mutation. In strict mode it rolls back a mutation that
+// breaks the network (see strictCheckpoint); otherwise, with a Genealogy set, the outermost
+// mutation is recorded as a lineage event.
+// Use it as: defer bp.mutationCheckpoint("AddConnection", nil)()
+func (bp *Phase) mutationCheckpoint(operator string, params map[string]interface{}) func() {
+	bp.mutationDepth++
+	revert := bp.strictCheckpoint(operator)
+	return func() {
+		bp.mutationDepth--
+		if reverted := revert(); !reverted && bp.mutationDepth == 0 {
+			bp.recordLineage(operator, params, nil)
+		}
+	}
+}
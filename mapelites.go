@@ -0,0 +1,435 @@
+package phase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// Descriptor maps a Phase onto one axis of the MAP-Elites grid. Values are clamped to
+// [Min, Max] and split into Bins equal-width cells.
+type Descriptor struct {
+	Name    string
+	Min     float64
+	Max     float64
+	Bins    int
+	Measure func(bp *Phase) float64
+}
+
+// bin returns the cell index of value along this axis.
+func (d Descriptor) bin(value float64) int {
+	if d.Bins <= 1 || d.Max <= d.Min || math.IsNaN(value) {
+		return 0
+	}
+	idx := int((value - d.Min) / (d.Max - d.Min) * float64(d.Bins))
+	if idx < 0 {
+		return 0
+	}
+	if idx >= d.Bins {
+		return d.Bins - 1
+	}
+	return idx
+}
+
+// NeuronCountDescriptor measures the number of non-input neurons.
+func NeuronCountDescriptor(min, max float64, bins int) Descriptor {
+	return Descriptor{Name: "neurons", Min: min, Max: max, Bins: bins, Measure: func(bp *Phase) float64 {
+		return float64(len(bp.Neurons) - len(bp.InputNodes))
+	}}
+}
+
+// NeuronTypeDescriptor measures the fraction of non-input neurons of the given type.
+func NeuronTypeDescriptor(neuronType string, bins int) Descriptor {
+	return Descriptor{Name: "type_" + neuronType, Min: 0, Max: 1, Bins: bins, Measure: func(bp *Phase) float64 {
+		return neuronTypeFraction(bp, neuronType)
+	}}
+}
+
+// RecurrentFractionDescriptor measures the fraction of non-input neurons that are rnn or lstm.
+func RecurrentFractionDescriptor(bins int) Descriptor {
+	return Descriptor{Name: "recurrent", Min: 0, Max: 1, Bins: bins, Measure: func(bp *Phase) float64 {
+		return neuronTypeFraction(bp, "rnn", "lstm")
+	}}
+}
+
+// neuronTypeFraction returns the share of non-input neurons whose type is one of types.
+func neuronTypeFraction(bp *Phase, types ...string) float64 {
+	total, matched := 0, 0
+	for _, neuron := range bp.Neurons {
+		if neuron.Type == "input" {
+			continue
+		}
+		total++
+		for _, t := range types {
+			if neuron.Type == t {
+				matched++
+				break
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(matched) / float64(total)
+}
+
+// Elite is the best Phase found for one cell of the grid.
+type Elite struct {
+	Cell        []int     // Bin index along each descriptor
+	Descriptors []float64 // Raw descriptor values
+	Fitness     float64
+	BP          *Phase
+}
+
+// MAPElitesConfig configures a MAPElites archive.
+type MAPElitesConfig struct {
+	Descriptors   []Descriptor  // Axes of the grid; at least one is required
+	Fitness       FitnessFunc   // Required
+	Mutation      MutationRates // Per-offspring operator probabilities
+	CrossoverRate float64       // Probability that an offspring is bred from two elites
+	BatchSize     int           // Offspring per Step; defaults to 32
+	Workers       int           // Concurrent fitness evaluations; defaults to runtime.NumCPU()
+	QDOffset      float64       // Subtracted from every fitness in QDScore so the score stays non-negative
+	Seed          int64         // Seeds parent selection and breeding decisions
+}
+
+// MAPElitesStats summarizes one Step.
+type MAPElitesStats struct {
+	Iteration   int
+	Inserted    int // Offspring that filled an empty cell or replaced a weaker elite
+	Occupied    int
+	Coverage    float64
+	QDScore     float64
+	BestFitness float64
+}
+
+// MAPElites is a quality-diversity archive: a grid of elites indexed by descriptors.
+// Each Step mutates elites from random occupied cells and keeps every offspring that is the
+// best in its own cell, so the archive becomes a library of diverse architectures.
+type MAPElites struct {
+	Config     MAPElitesConfig
+	Elites     map[int]*Elite // Keyed by flattened cell index
+	Iterations int            // Number of completed steps
+
+	rng *rand.Rand
+}
+
+// NewMAPElites returns an empty archive.
+func NewMAPElites(cfg MAPElitesConfig) (*MAPElites, error) {
+	if len(cfg.Descriptors) == 0 {
+		return nil, fmt.Errorf("map-elites: at least one descriptor is required")
+	}
+	for _, d := range cfg.Descriptors {
+		if d.Measure == nil {
+			return nil, fmt.Errorf("map-elites: descriptor %q has no Measure", d.Name)
+		}
+		if d.Bins < 1 {
+			return nil, fmt.Errorf("map-elites: descriptor %q needs at least one bin, got %d", d.Name, d.Bins)
+		}
+	}
+	if cfg.Fitness == nil {
+		return nil, fmt.Errorf("map-elites: fitness function is required")
+	}
+	if cfg.BatchSize < 1 {
+		cfg.BatchSize = 32
+	}
+	if cfg.Workers < 1 {
+		cfg.Workers = runtime.NumCPU()
+	}
+	return &MAPElites{
+		Config: cfg,
+		Elites: make(map[int]*Elite),
+		rng:    rand.New(rand.NewSource(cfg.Seed)),
+	}, nil
+}
+
+// Cells returns the total number of cells in the grid.
+func (m *MAPElites) Cells() int {
+	total := 1
+	for _, d := range m.Config.Descriptors {
+		total *= d.Bins
+	}
+	return total
+}
+
+// Describe measures bp on every descriptor and returns its cell and raw values.
+func (m *MAPElites) Describe(bp *Phase) (cell []int, values []float64) {
+	cell = make([]int, len(m.Config.Descriptors))
+	values = make([]float64, len(m.Config.Descriptors))
+	for i, d := range m.Config.Descriptors {
+		values[i] = d.Measure(bp)
+		cell[i] = d.bin(values[i])
+	}
+	return cell, values
+}
+
+// cellKey flattens a cell into a single index (row-major over the descriptors).
+func (m *MAPElites) cellKey(cell []int) int {
+	key := 0
+	for i, d := range m.Config.Descriptors {
+		key = key*d.Bins + cell[i]
+	}
+	return key
+}
+
+// Insert evaluates bp and stores it if its cell is empty or holds a less fit elite.
+// It reports whether bp was stored.
+func (m *MAPElites) Insert(bp *Phase) bool {
+	return m.place(m.evaluate(bp))
+}
+
+// evaluate scores and describes bp without touching the grid.
+func (m *MAPElites) evaluate(bp *Phase) *Elite {
+	fitness := m.Config.Fitness(bp)
+	if math.IsNaN(fitness) {
+		fitness = math.Inf(-1)
+	}
+	cell, values := m.Describe(bp)
+	return &Elite{Cell: cell, Descriptors: values, Fitness: fitness, BP: bp}
+}
+
+// place stores candidate if it beats the elite in its cell.
+func (m *MAPElites) place(candidate *Elite) bool {
+	key := m.cellKey(candidate.Cell)
+	if current, exists := m.Elites[key]; exists && current.Fitness >= candidate.Fitness {
+		return false
+	}
+	m.Elites[key] = candidate
+	return true
+}
+
+// Step breeds BatchSize offspring from elites of random occupied cells, evaluates them
+// concurrently and places each one in its cell. The archive must hold at least one elite.
+func (m *MAPElites) Step() (MAPElitesStats, error) {
+	if len(m.Elites) == 0 {
+		return MAPElitesStats{}, fmt.Errorf("map-elites: archive is empty; Insert a seed first")
+	}
+	parents := m.sortedElites()
+
+	offspring := make([]*Phase, m.Config.BatchSize)
+	for i := range offspring {
+		first := parents[m.rng.Intn(len(parents))]
+		var child *Phase
+		if len(parents) > 1 && m.rng.Float64() < m.Config.CrossoverRate {
+			second := parents[m.rng.Intn(len(parents))]
+			child = crossover(first.BP, second.BP, first.Fitness, second.Fitness, m.rng)
+		} else {
+			child = first.BP.Copy()
+		}
+		child.seedRand(m.rng.Int63())
+		applyMutationRates(child, m.Config.Mutation, m.rng)
+		offspring[i] = child
+	}
+
+	candidates := make([]*Elite, len(offspring))
+	pending := make(chan int, len(offspring))
+	for i := range offspring {
+		pending <- i
+	}
+	close(pending)
+	var wg sync.WaitGroup
+	for w := 0; w < m.Config.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range pending {
+				candidates[i] = m.evaluate(offspring[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	inserted := 0
+	for _, candidate := range candidates {
+		if m.place(candidate) {
+			inserted++
+		}
+	}
+	m.Iterations++
+
+	stats := m.Stats()
+	stats.Inserted = inserted
+	return stats, nil
+}
+
+// Run calls Step for the given number of iterations, stopping early when ctx is done.
+// It returns the statistics of the last completed step.
+func (m *MAPElites) Run(ctx context.Context, iterations int) (MAPElitesStats, error) {
+	var stats MAPElitesStats
+	for i := 0; i < iterations; i++ {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		var err error
+		if stats, err = m.Step(); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// Coverage returns the fraction of cells that hold an elite.
+func (m *MAPElites) Coverage() float64 {
+	return float64(len(m.Elites)) / float64(m.Cells())
+}
+
+// QDScore returns the sum of (Fitness - QDOffset) over all elites, rewarding both
+// quality and coverage.
+func (m *MAPElites) QDScore() float64 {
+	score := 0.0
+	for _, elite := range m.Elites {
+		score += elite.Fitness - m.Config.QDOffset
+	}
+	return score
+}
+
+// Best returns the fittest elite, or nil when the archive is empty.
+func (m *MAPElites) Best() *Elite {
+	var best *Elite
+	for _, elite := range m.sortedElites() {
+		if best == nil || elite.Fitness > best.Fitness {
+			best = elite
+		}
+	}
+	return best
+}
+
+// Stats summarizes the archive. Inserted is left at zero.
+func (m *MAPElites) Stats() MAPElitesStats {
+	stats := MAPElitesStats{
+		Iteration:   m.Iterations,
+		Occupied:    len(m.Elites),
+		Coverage:    m.Coverage(),
+		QDScore:     m.QDScore(),
+		BestFitness: math.Inf(-1),
+	}
+	if best := m.Best(); best != nil {
+		stats.BestFitness = best.Fitness
+	}
+	return stats
+}
+
+// sortedElites returns the elites ordered by cell index, so parent selection is reproducible.
+func (m *MAPElites) sortedElites() []*Elite {
+	keys := make([]int, 0, len(m.Elites))
+	for key := range m.Elites {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	elites := make([]*Elite, len(keys))
+	for i, key := range keys {
+		elites[i] = m.Elites[key]
+	}
+	return elites
+}
+
+// mapElitesFile is the on-disk form of an archive.
+type mapElitesFile struct {
+	Descriptors []mapElitesAxis `json:"descriptors"`
+	Iterations  int             `json:"iterations"`
+	Coverage    float64         `json:"coverage"`
+	QDScore     float64         `json:"qd_score"`
+	Elites      []eliteFile     `json:"elites"`
+}
+
+type mapElitesAxis struct {
+	Name string  `json:"name"`
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	Bins int     `json:"bins"`
+}
+
+type eliteFile struct {
+	Cell        []int           `json:"cell"`
+	Descriptors []float64       `json:"descriptors"`
+	Fitness     float64         `json:"fitness"`
+	Phase       json.RawMessage `json:"phase"`
+}
+
+// Save writes the archive, with its coverage and QD score, to a JSON file.
+func (m *MAPElites) Save(fileName string) error {
+	out := mapElitesFile{
+		Iterations: m.Iterations,
+		Coverage:   m.Coverage(),
+		QDScore:    replaceNaN(m.QDScore()),
+	}
+	for _, d := range m.Config.Descriptors {
+		out.Descriptors = append(out.Descriptors, mapElitesAxis{Name: d.Name, Min: d.Min, Max: d.Max, Bins: d.Bins})
+	}
+	for _, elite := range m.sortedElites() {
+		data, err := json.Marshal(elite.BP)
+		if err != nil {
+			return fmt.Errorf("failed to serialize elite in cell %v: %v", elite.Cell, err)
+		}
+		fitness := elite.Fitness
+		if math.IsInf(fitness, 0) || math.IsNaN(fitness) {
+			fitness = -math.MaxFloat64
+		}
+		out.Elites = append(out.Elites, eliteFile{Cell: elite.Cell, Descriptors: elite.Descriptors, Fitness: fitness, Phase: data})
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize MAP-Elites archive: %v", err)
+	}
+	if err := os.WriteFile(fileName, data, 0644); err != nil {
+		return fmt.Errorf("failed to write MAP-Elites archive to '%s': %v", fileName, err)
+	}
+	return nil
+}
+
+// LoadMAPElites reads an archive written by Save. Descriptor functions cannot be stored,
+// so cfg must declare the same descriptors (names and bins) the archive was saved with.
+// Elites are placed by their stored cells without re-evaluating fitness.
+func LoadMAPElites(fileName string, cfg MAPElitesConfig) (*MAPElites, error) {
+	m, err := NewMAPElites(cfg)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MAP-Elites archive '%s': %v", fileName, err)
+	}
+	var in mapElitesFile
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, fmt.Errorf("failed to parse MAP-Elites archive '%s': %v", fileName, err)
+	}
+	if len(in.Descriptors) != len(cfg.Descriptors) {
+		return nil, fmt.Errorf("map-elites: archive has %d descriptors, config has %d", len(in.Descriptors), len(cfg.Descriptors))
+	}
+	for i, axis := range in.Descriptors {
+		if d := cfg.Descriptors[i]; axis.Name != d.Name || axis.Bins != d.Bins {
+			return nil, fmt.Errorf("map-elites: descriptor %d is %q with %d bins in the archive, %q with %d bins in the config",
+				i, axis.Name, axis.Bins, d.Name, d.Bins)
+		}
+	}
+
+	m.Iterations = in.Iterations
+	for _, stored := range in.Elites {
+		if len(stored.Cell) != len(cfg.Descriptors) {
+			return nil, fmt.Errorf("map-elites: elite cell %v does not match %d descriptors", stored.Cell, len(cfg.Descriptors))
+		}
+		for i, idx := range stored.Cell {
+			if idx < 0 || idx >= cfg.Descriptors[i].Bins {
+				return nil, fmt.Errorf("map-elites: elite cell %v out of range", stored.Cell)
+			}
+		}
+		bp := NewPhase()
+		if err := bp.DeserializesFromJSON(string(stored.Phase)); err != nil {
+			return nil, fmt.Errorf("failed to load elite in cell %v: %v", stored.Cell, err)
+		}
+		m.Elites[m.cellKey(stored.Cell)] = &Elite{
+			Cell:        stored.Cell,
+			Descriptors: stored.Descriptors,
+			Fitness:     stored.Fitness,
+			BP:          bp,
+		}
+	}
+	return m, nil
+}
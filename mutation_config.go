@@ -0,0 +1,372 @@
+package phase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+)
+
+// OperatorConfig sets how often a mutation operator fires and how strong it is.
+type OperatorConfig struct {
+	Probability float64 `json:"probability"`         // Chance of applying the operator per ApplyMutations call
+	Magnitude   float64 `json:"magnitude,omitempty"` // Operator-specific strength; 0 uses the built-in default
+}
+
+// Adaptation modes of MutationConfig.
+const (
+	AdaptationNone         = ""              // Magnitudes stay fixed
+	AdaptationOneFifth     = "one_fifth"     // Sigma follows Rechenberg's 1/5th success rule (see ReportOutcome)
+	AdaptationSelfAdaptive = "self_adaptive" // Every Phase carries its own log-normally mutated MutationSigma
+)
+
+// MutationConfig chooses the operators ApplyMutations runs, with their probabilities and
+// magnitudes. It can be loaded from JSON with LoadMutationConfig.
+type MutationConfig struct {
+	AddNeuron         OperatorConfig `json:"add_neuron"`          // Magnitude: std dev of the new bias and weights (0.1)
+	AddConnection     OperatorConfig `json:"add_connection"`      // Magnitude: std dev of the new weight (0.1)
+	RemoveConnection  OperatorConfig `json:"remove_connection"`   // No magnitude
+	RemoveNeuron      OperatorConfig `json:"remove_neuron"`       // No magnitude
+	AdjustWeights     OperatorConfig `json:"adjust_weights"`      // Magnitude: std dev of the weight perturbation (0.05), scaled by sigma
+	AdjustBiases      OperatorConfig `json:"adjust_biases"`       // Magnitude: std dev of the bias perturbation (0.05), scaled by sigma
+	ChangeActivation  OperatorConfig `json:"change_activation"`   // No magnitude
+	ChangeNeuronType  OperatorConfig `json:"change_neuron_type"`  // No magnitude
+	ChangeNeuronTypes OperatorConfig `json:"change_neuron_types"` // Magnitude: percentage of non-input neurons retyped (10)
+
+	NeuronType     string `json:"neuron_type,omitempty"` // Type of neurons added by AddNeuron; empty picks one at random
+	MinConnections int    `json:"min_connections"`       // Incoming connections of an added neuron; defaults to 1
+	MaxConnections int    `json:"max_connections"`       // Defaults to 3
+
+	Adaptation  string  `json:"adaptation,omitempty"`   // AdaptationNone, AdaptationOneFifth or AdaptationSelfAdaptive
+	Sigma       float64 `json:"sigma"`                  // Multiplier of the weight and bias step sizes; defaults to 1
+	AdaptWindow int     `json:"adapt_window,omitempty"` // Outcomes per 1/5th-rule update; defaults to 10
+	AdaptFactor float64 `json:"adapt_factor,omitempty"` // 1/5th-rule shrink factor in (0, 1); defaults to 0.82
+	Tau         float64 `json:"tau,omitempty"`          // Learning rate of self-adaptive sigma; defaults to 1/sqrt(2)
+	MinSigma    float64 `json:"min_sigma,omitempty"`    // Lower bound on any adapted sigma; defaults to 1e-3
+	MaxSigma    float64 `json:"max_sigma,omitempty"`    // Upper bound on any adapted sigma; defaults to 100
+
+	trials    int
+	successes int
+}
+
+// DefaultMutationConfig returns the operator probabilities of DefaultMutationRates with the
+// built-in magnitudes and no adaptation.
+func DefaultMutationConfig() MutationConfig {
+	return DefaultMutationRates().MutationConfig()
+}
+
+// MutationConfig converts the rates to a MutationConfig that adds dense neurons with one to
+// three connections and uses the built-in magnitudes.
+func (r MutationRates) MutationConfig() MutationConfig {
+	return MutationConfig{
+		AddNeuron:        OperatorConfig{Probability: r.AddNeuron},
+		AddConnection:    OperatorConfig{Probability: r.AddConnection},
+		RemoveConnection: OperatorConfig{Probability: r.RemoveConnection},
+		RemoveNeuron:     OperatorConfig{Probability: r.RemoveNeuron},
+		AdjustWeights:    OperatorConfig{Probability: r.AdjustWeights},
+		AdjustBiases:     OperatorConfig{Probability: r.AdjustBiases},
+		ChangeActivation: OperatorConfig{Probability: r.ChangeActivation},
+		ChangeNeuronType: OperatorConfig{Probability: r.ChangeNeuronType},
+		NeuronType:       "dense",
+		MinConnections:   1,
+		MaxConnections:   3,
+		Sigma:            1,
+	}
+}
+
+// LoadMutationConfig reads a MutationConfig from a JSON file. Unknown fields are rejected so
+// misspelled operators do not go unnoticed.
+func LoadMutationConfig(fileName string) (MutationConfig, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return MutationConfig{}, fmt.Errorf("failed to read mutation config '%s': %v", fileName, err)
+	}
+	return ParseMutationConfig(data)
+}
+
+// ParseMutationConfig decodes a MutationConfig from JSON and checks it.
+func ParseMutationConfig(data []byte) (MutationConfig, error) {
+	cfg := MutationConfig{Sigma: 1}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return MutationConfig{}, fmt.Errorf("failed to parse mutation config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return MutationConfig{}, err
+	}
+	return cfg, nil
+}
+
+// Validate checks probabilities, magnitudes and the adaptation mode.
+func (c *MutationConfig) Validate() error {
+	for name, op := range c.operators() {
+		if op.Probability < 0 || op.Probability > 1 || math.IsNaN(op.Probability) {
+			return fmt.Errorf("mutation config: %s probability %v outside [0, 1]", name, op.Probability)
+		}
+		if op.Magnitude < 0 || math.IsNaN(op.Magnitude) {
+			return fmt.Errorf("mutation config: %s magnitude %v is negative", name, op.Magnitude)
+		}
+	}
+	switch c.Adaptation {
+	case AdaptationNone, AdaptationOneFifth, AdaptationSelfAdaptive:
+	default:
+		return fmt.Errorf("mutation config: unknown adaptation %q", c.Adaptation)
+	}
+	if c.Sigma < 0 || math.IsNaN(c.Sigma) {
+		return fmt.Errorf("mutation config: sigma %v is negative", c.Sigma)
+	}
+	if c.MinSigma < 0 || c.MaxSigma < 0 || math.IsNaN(c.MinSigma) || math.IsNaN(c.MaxSigma) {
+		return fmt.Errorf("mutation config: min_sigma %v or max_sigma %v is negative", c.MinSigma, c.MaxSigma)
+	}
+	if lo, hi := c.sigmaBounds(); lo > hi {
+		return fmt.Errorf("mutation config: min_sigma %v above max_sigma %v", lo, hi)
+	}
+	if c.AdaptFactor < 0 || c.AdaptFactor >= 1 {
+		return fmt.Errorf("mutation config: adapt_factor %v outside (0, 1)", c.AdaptFactor)
+	}
+	if c.MaxConnections != 0 && c.MaxConnections < c.MinConnections {
+		return fmt.Errorf("mutation config: max_connections %d below min_connections %d", c.MaxConnections, c.MinConnections)
+	}
+	if c.NeuronType != "" && !knownNeuronTypes[c.NeuronType] {
+		return fmt.Errorf("mutation config: unknown neuron_type %q", c.NeuronType)
+	}
+	return nil
+}
+
+// operators lists the operator settings by JSON name.
+func (c *MutationConfig) operators() map[string]OperatorConfig {
+	return map[string]OperatorConfig{
+		"add_neuron":          c.AddNeuron,
+		"add_connection":      c.AddConnection,
+		"remove_connection":   c.RemoveConnection,
+		"remove_neuron":       c.RemoveNeuron,
+		"adjust_weights":      c.AdjustWeights,
+		"adjust_biases":       c.AdjustBiases,
+		"change_activation":   c.ChangeActivation,
+		"change_neuron_type":  c.ChangeNeuronType,
+		"change_neuron_types": c.ChangeNeuronTypes,
+	}
+}
+
+// ReportOutcome feeds the result of evaluating a mutated individual back into the 1/5th
+// rule: after every AdaptWindow outcomes, Sigma grows when more than a fifth of them improved
+// and shrinks when fewer did. It does nothing in the other adaptation modes.
+func (c *MutationConfig) ReportOutcome(improved bool) {
+	if c.Adaptation != AdaptationOneFifth {
+		return
+	}
+	c.trials++
+	if improved {
+		c.successes++
+	}
+	window := c.AdaptWindow
+	if window < 1 {
+		window = 10
+	}
+	if c.trials < window {
+		return
+	}
+	factor := c.AdaptFactor
+	if factor == 0 {
+		factor = 0.82
+	}
+	rate := float64(c.successes) / float64(c.trials)
+	switch {
+	case rate > 0.2:
+		c.Sigma = c.clampSigma(c.sigma() / factor)
+	case rate < 0.2:
+		c.Sigma = c.clampSigma(c.sigma() * factor)
+	}
+	c.trials, c.successes = 0, 0
+}
+
+// sigma returns the configured step multiplier, treating 0 as 1.
+func (c *MutationConfig) sigma() float64 {
+	if c.Sigma == 0 {
+		return 1
+	}
+	return c.Sigma
+}
+
+func (c *MutationConfig) clampSigma(sigma float64) float64 {
+	lo, hi := c.sigmaBounds()
+	return math.Max(lo, math.Min(hi, sigma))
+}
+
+// sigmaBounds returns MinSigma and MaxSigma with their defaults filled in.
+func (c *MutationConfig) sigmaBounds() (float64, float64) {
+	lo, hi := c.MinSigma, c.MaxSigma
+	if lo == 0 {
+		lo = 1e-3
+	}
+	if hi == 0 {
+		hi = 100
+	}
+	return lo, hi
+}
+
+// AppliedMutation describes one operator that ApplyMutations ran.
+type AppliedMutation struct {
+	Operator  string
+	Magnitude float64 // Magnitude actually used, after defaults and sigma; 0 for operators without one
+	Target    int     // Neuron the operator changed, or -1 when it changed nothing or several neurons
+	Detail    string
+}
+
+// MutationReport describes what ApplyMutations changed.
+type MutationReport struct {
+	Sigma   float64 // Step multiplier used for this call
+	Applied []AppliedMutation
+}
+
+// Changed reports whether any operator ran.
+func (r MutationReport) Changed() bool {
+	return len(r.Applied) > 0
+}
+
+// String lists the operators that ran, e.g. "AdjustWeights(0.05)@7, AddConnection(0.1)@3".
+func (r MutationReport) String() string {
+	if len(r.Applied) == 0 {
+		return "no mutations"
+	}
+	parts := make([]string, len(r.Applied))
+	for i, m := range r.Applied {
+		part := m.Operator
+		if m.Magnitude != 0 {
+			part += fmt.Sprintf("(%g)", m.Magnitude)
+		}
+		if m.Target >= 0 {
+			part += fmt.Sprintf("@%d", m.Target)
+		}
+		if m.Detail != "" {
+			part += " " + m.Detail
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ApplyMutations runs each operator of cfg with its probability, drawing the decisions from
+// rng, and reports what changed. Under AdaptationSelfAdaptive the Phase's MutationSigma is
+// first perturbed log-normally and then used as the step multiplier, so successful step sizes
+// are inherited along with the network; otherwise cfg.Sigma is used.
+//
+// Every operator runs under strict mode like its exported counterpart, and the report only
+// lists operators that changed the network and were not rolled back. With a Genealogy set,
+// the call is recorded as one ApplyMutations lineage event, and only when something changed.
+func (bp *Phase) ApplyMutations(cfg *MutationConfig, rng *rand.Rand) MutationReport {
+	sigma := cfg.sigma()
+	if cfg.Adaptation == AdaptationSelfAdaptive {
+		if bp.MutationSigma <= 0 {
+			bp.MutationSigma = sigma
+		}
+		tau := cfg.Tau
+		if tau == 0 {
+			tau = 1 / math.Sqrt2
+		}
+		bp.MutationSigma = cfg.clampSigma(bp.MutationSigma * math.Exp(tau*rng.NormFloat64()))
+		sigma = bp.MutationSigma
+	}
+	report := MutationReport{Sigma: sigma}
+
+	// The operators are nested in this call, so they record no lineage events of their own.
+	bp.mutationDepth++
+	defer func() {
+		bp.mutationDepth--
+		if report.Changed() && bp.mutationDepth == 0 {
+			bp.recordLineage("ApplyMutations", map[string]interface{}{"sigma": sigma, "mutations": report.String()}, nil)
+		}
+	}()
+
+	magnitude := func(op OperatorConfig, fallback float64) float64 {
+		if op.Magnitude > 0 {
+			return op.Magnitude
+		}
+		return fallback
+	}
+	// apply runs mutate with op's probability and reports it when it changed the network
+	// and strict mode did not roll it back.
+	apply := func(op OperatorConfig, mutate func() (AppliedMutation, bool)) {
+		if rng.Float64() >= op.Probability {
+			return
+		}
+		reverts := bp.reverts
+		if applied, changed := mutate(); changed && bp.reverts == reverts {
+			report.Applied = append(report.Applied, applied)
+		}
+	}
+
+	apply(cfg.AddNeuron, func() (AppliedMutation, bool) {
+		minConns, maxConns := cfg.MinConnections, cfg.MaxConnections
+		if minConns < 1 {
+			minConns = 1
+		}
+		if maxConns < minConns {
+			maxConns = max(minConns, 3)
+		}
+		scale := magnitude(cfg.AddNeuron, defaultInitScale)
+		neuron := bp.addNeuronFromPreOutputs(cfg.NeuronType, "", minConns, maxConns, scale, rng)
+		if neuron == nil {
+			return AppliedMutation{}, false
+		}
+		neuron.IsNew = false
+		return AppliedMutation{Operator: "AddNeuron", Magnitude: scale, Target: neuron.ID, Detail: neuron.Type}, true
+	})
+	apply(cfg.AddConnection, func() (AppliedMutation, bool) {
+		scale := magnitude(cfg.AddConnection, defaultInitScale)
+		source, target := bp.addConnection(scale, rng)
+		return AppliedMutation{Operator: "AddConnection", Magnitude: scale, Target: target, Detail: fmt.Sprintf("from %d", source)}, target >= 0
+	})
+	apply(cfg.RemoveConnection, func() (AppliedMutation, bool) {
+		before := bp.connectionCount()
+		bp.removeConnection(rng)
+		return AppliedMutation{Operator: "RemoveConnection", Target: -1}, bp.connectionCount() < before
+	})
+	apply(cfg.RemoveNeuron, func() (AppliedMutation, bool) {
+		id := bp.removeRandomNeuron(rng)
+		return AppliedMutation{Operator: "RemoveNeuron", Target: id}, id >= 0
+	})
+	apply(cfg.AdjustWeights, func() (AppliedMutation, bool) {
+		step := magnitude(cfg.AdjustWeights, defaultWeightStep) * sigma
+		id := bp.adjustWeights(step, rng)
+		return AppliedMutation{Operator: "AdjustWeights", Magnitude: step, Target: id}, id >= 0
+	})
+	apply(cfg.AdjustBiases, func() (AppliedMutation, bool) {
+		step := magnitude(cfg.AdjustBiases, defaultBiasStep) * sigma
+		id := bp.adjustBiases(step, rng)
+		return AppliedMutation{Operator: "AdjustBiases", Magnitude: step, Target: id}, id >= 0
+	})
+	apply(cfg.ChangeActivation, func() (AppliedMutation, bool) {
+		id := bp.changeActivationFunction(rng)
+		if id < 0 {
+			return AppliedMutation{}, false
+		}
+		return AppliedMutation{Operator: "ChangeActivation", Target: id, Detail: bp.Neurons[id].Activation}, true
+	})
+	apply(cfg.ChangeNeuronType, func() (AppliedMutation, bool) {
+		id := bp.changeSingleNeuronType(rng)
+		if id < 0 {
+			return AppliedMutation{}, false
+		}
+		return AppliedMutation{Operator: "ChangeNeuronType", Target: id, Detail: bp.Neurons[id].Type}, true
+	})
+	apply(cfg.ChangeNeuronTypes, func() (AppliedMutation, bool) {
+		percentage := magnitude(cfg.ChangeNeuronTypes, 10)
+		changed := bp.changePercentageOfNeuronsTypes(percentage, rng)
+		return AppliedMutation{Operator: "ChangeNeuronTypes", Magnitude: percentage, Target: -1, Detail: fmt.Sprintf("%d neurons", changed)}, changed > 0
+	})
+	return report
+}
+
+// connectionCount returns the number of connections in the network.
+func (bp *Phase) connectionCount() int {
+	count := 0
+	for _, neuron := range bp.Neurons {
+		count += len(neuron.Connections)
+	}
+	return count
+}
@@ -14,24 +14,27 @@ var possibleActivations = []string{"relu", "sigmoid", "tanh", "leaky_relu", "elu
 // AddRandomNeuron adds a new neuron of the given type (or random type if empty) to the Phase.
 // It creates random connections from existing neurons, sets a random bias, and chooses an activation if needed.
 func (bp *Phase) AddRandomNeuron(neuronType string, activation string, minConnections, maxConnections int) *Neuron {
+	defer bp.mutationCheckpoint("AddRandomNeuron", map[string]interface{}{"type": neuronType, "activation": activation, "min_connections": minConnections, "max_connections": maxConnections})()
+
+	rng := bp.rng()
 	// If neuronType is not provided, pick a random type
 	if neuronType == "" {
-		neuronType = neuronTypes[rand.Intn(len(neuronTypes))]
+		neuronType = neuronTypes[rng.Intn(len(neuronTypes))]
 	}
 
 	// If activation not provided, pick a random one
 	if activation == "" {
-		activation = possibleActivations[rand.Intn(len(possibleActivations))]
+		activation = possibleActivations[rng.Intn(len(possibleActivations))]
 	}
 
-	// Determine the new neuron's ID
-	newID := bp.GetNextNeuronID()
+	// Determine the new neuron's ID; the tracker keeps it unique across the population
+	newID := Innovations.NodeID(bp.GetNextNeuronID())
 
 	// Create a new neuron
 	newNeuron := &Neuron{
 		ID:         newID,
 		Type:       neuronType,
-		Bias:       rand.NormFloat64() * 0.1, // Small random bias
+		Bias:       rng.NormFloat64() * 0.1, // Small random bias
 		Activation: activation,
 	}
 
@@ -48,22 +51,22 @@ func (bp *Phase) AddRandomNeuron(neuronType string, activation string, minConnec
 	if maxConnections < minConnections {
 		maxConnections = minConnections
 	}
-	numConns := rand.Intn(maxConnections-minConnections+1) + minConnections
+	numConns := rng.Intn(maxConnections-minConnections+1) + minConnections
 	if numConns > numExisting {
 		numConns = numExisting
 	}
 
 	// Get list of existing neuron IDs and shuffle
-	existingIDs := bp.getAllNeuronIDs()
-	rand.Shuffle(len(existingIDs), func(i, j int) { existingIDs[i], existingIDs[j] = existingIDs[j], existingIDs[i] })
+	existingIDs := bp.sortedNeuronIDs()
+	rng.Shuffle(len(existingIDs), func(i, j int) { existingIDs[i], existingIDs[j] = existingIDs[j], existingIDs[i] })
 
 	// Pick a subset of existing neurons to connect from
 	selectedIDs := existingIDs[:numConns]
 
 	// Create connections from selected neurons to the new neuron
 	for _, sourceID := range selectedIDs {
-		weight := rand.NormFloat64() * 0.1
-		newNeuron.Connections = append(newNeuron.Connections, []float64{float64(sourceID), weight})
+		weight := rng.NormFloat64() * 0.1
+		newNeuron.Connections = append(newNeuron.Connections, newTrackedConnection(sourceID, newID, weight))
 	}
 
 	// Special handling for certain neuron types
@@ -79,15 +82,16 @@ func (bp *Phase) AddRandomNeuron(neuronType string, activation string, minConnec
 	} else if neuronType == "cnn" && len(newNeuron.Kernels) == 0 {
 		// Initialize default kernels if none provided
 		newNeuron.Kernels = [][]float64{
-			{rand.Float64(), rand.Float64()},
-			{rand.Float64(), rand.Float64()},
+			{rng.Float64(), rng.Float64()},
+			{rng.Float64(), rng.Float64()},
 		}
 	} else if neuronType == "batch_norm" && newNeuron.BatchNormParams == nil {
 		newNeuron.BatchNormParams = &BatchNormParams{
-			Gamma: 1.0,
-			Beta:  0.0,
-			Mean:  0.0,
-			Var:   1.0,
+			Gamma:     1.0,
+			Beta:      0.0,
+			Mean:      0.0,
+			Var:       1.0,
+			SumInputs: true,
 		}
 	}
 
@@ -104,12 +108,14 @@ func (bp *Phase) AddRandomNeuron(neuronType string, activation string, minConnec
 // RewireOutputsThroughNewNeuron ensures the newly added neuron is
 // the *only* path from the old pre-output neurons to the outputs.
 func (bp *Phase) RewireOutputsThroughNewNeuron(newNeuronID int) {
+	defer bp.mutationCheckpoint("RewireOutputsThroughNewNeuron", map[string]interface{}{"neuron": newNeuronID})()
+
 	for _, outID := range bp.OutputNodes {
 		outNeuron := bp.Neurons[outID]
-		var newConns [][]float64
+		var newConns []Connection
 		// Keep only connections coming from neurons already marked as new
 		for _, conn := range outNeuron.Connections {
-			srcID := int(conn[0])
+			srcID := conn.Source
 			// If the source neuron is marked as new, keep its connection
 			if bp.Neurons[srcID].IsNew {
 				newConns = append(newConns, conn)
@@ -117,8 +123,8 @@ func (bp *Phase) RewireOutputsThroughNewNeuron(newNeuronID int) {
 		}
 		// Add a connection from the new neuron if it doesn't already exist.
 		if !bp.connectionExists(newNeuronID, outID) {
-			weight := rand.NormFloat64() * 0.1 // small random weight
-			newConns = append(newConns, []float64{float64(newNeuronID), weight})
+			weight := bp.rng().NormFloat64() * 0.1 // small random weight
+			newConns = append(newConns, newTrackedConnection(newNeuronID, outID, weight))
 			if bp.Debug {
 				fmt.Printf("Added connection from new neuron %d to output neuron %d with weight %f\n", newNeuronID, outID, weight)
 			}
@@ -144,99 +150,259 @@ func (bp *Phase) GetNextNeuronID() int {
 	return maxID + 1
 }
 
+// Default magnitudes of the mutation operators; MutationConfig can override them.
+const (
+	defaultInitScale  = 0.1  // Std dev of weights and biases of new connections and neurons
+	defaultWeightStep = 0.05 // Std dev of AdjustWeights perturbations
+	defaultBiasStep   = 0.05 // Std dev of AdjustBiases perturbations
+)
+
 // AddConnection adds a new connection between two random neurons.
 func (bp *Phase) AddConnection() {
-	sourceID, targetID := bp.getRandomConnectionPair()
+	bp.addConnection(defaultInitScale, bp.rng())
+}
+
+// addConnection adds a connection whose weight has standard deviation scale and returns its
+// endpoints, or -1, -1 when every pair is already connected.
+func (bp *Phase) addConnection(scale float64, rng *rand.Rand) (int, int) {
+	defer bp.mutationCheckpoint("AddConnection", map[string]interface{}{"scale": scale})()
+
+	sourceID, targetID := bp.getRandomConnectionPair(rng)
 	if sourceID == -1 || targetID == -1 {
-		return
+		return -1, -1
 	}
-	weight := rand.NormFloat64() * 0.1
-	bp.Neurons[targetID].Connections = append(bp.Neurons[targetID].Connections, []float64{float64(sourceID), weight})
+	weight := rng.NormFloat64() * scale
+	bp.Neurons[targetID].Connections = append(bp.Neurons[targetID].Connections, newTrackedConnection(sourceID, targetID, weight))
 	if bp.Debug {
 		fmt.Printf("Added connection from Neuron %d to Neuron %d (weight=%f)\n", sourceID, targetID, weight)
 	}
+	return sourceID, targetID
 }
 
 // RemoveConnection removes a random connection from a random neuron.
 func (bp *Phase) RemoveConnection() {
-	neuronIDs := bp.getAllNeuronIDs()
+	bp.removeConnection(bp.rng())
+}
+
+// removeConnection removes a random connection, drawing from rng.
+func (bp *Phase) removeConnection(rng *rand.Rand) {
+	defer bp.mutationCheckpoint("RemoveConnection", nil)()
+
+	neuronIDs := bp.sortedNeuronIDs()
 	if len(neuronIDs) == 0 {
 		return
 	}
-	neuronID := neuronIDs[rand.Intn(len(neuronIDs))]
+	neuronID := neuronIDs[rng.Intn(len(neuronIDs))]
 	neuron := bp.Neurons[neuronID]
 	if len(neuron.Connections) == 0 {
 		return
 	}
-	connIndex := rand.Intn(len(neuron.Connections))
+	connIndex := rng.Intn(len(neuron.Connections))
 	removedConn := neuron.Connections[connIndex]
-	neuron.Connections = append(neuron.Connections[:connIndex], neuron.Connections[connIndex+1:]...)
+	removeConnectionAt(neuron, connIndex)
+	if bp.Debug {
+		fmt.Printf("Removed connection from Neuron %d to Neuron %d\n", removedConn.Source, neuronID)
+	}
+}
+
+// removeConnectionAt drops the connection at index, and for LSTM neurons the gate weights at
+// the same position, so the gates stay aligned with the connections.
+func removeConnectionAt(neuron *Neuron, index int) {
+	neuron.Connections = append(neuron.Connections[:index], neuron.Connections[index+1:]...)
+	if neuron.Type != "lstm" {
+		return
+	}
+	for gate, weights := range neuron.GateWeights {
+		if index < len(weights) {
+			neuron.GateWeights[gate] = append(weights[:index], weights[index+1:]...)
+		}
+	}
+}
+
+// RemoveNeuron deletes a hidden neuron together with its incoming connections and every
+// connection that leaves it, including the matching LSTM gate weights of its targets.
+// Input and output neurons cannot be removed. It reports whether the neuron was removed.
+func (bp *Phase) RemoveNeuron(id int) bool {
+	defer bp.mutationCheckpoint("RemoveNeuron", map[string]interface{}{"neuron": id})()
+
+	removed := bp.removeNeuron(id)
 	if bp.Debug {
-		fmt.Printf("Removed connection from Neuron %d to Neuron %d\n", int(removedConn[0]), neuronID)
+		if removed {
+			fmt.Printf("Removed Neuron %d\n", id)
+		} else {
+			fmt.Printf("Neuron %d was not removed: missing, input or output\n", id)
+		}
+	}
+	return removed
+}
+
+// RemoveRandomNeuron removes a random hidden neuron and returns its ID, or -1 when the
+// network has no hidden neurons.
+func (bp *Phase) RemoveRandomNeuron() int {
+	return bp.removeRandomNeuron(bp.rng())
+}
+
+// removeRandomNeuron removes a random hidden neuron, drawing from rng.
+func (bp *Phase) removeRandomNeuron(rng *rand.Rand) int {
+	defer bp.mutationCheckpoint("RemoveRandomNeuron", nil)()
+
+	hidden := bp.hiddenNeuronIDs()
+	if len(hidden) == 0 {
+		return -1
+	}
+	id := hidden[rng.Intn(len(hidden))]
+	bp.removeNeuron(id)
+	if bp.Debug {
+		fmt.Printf("Removed random Neuron %d\n", id)
+	}
+	return id
+}
+
+// PruneDeadNeurons deletes every hidden neuron that cannot reach an output through enabled
+// connections, and returns their IDs in ascending order.
+func (bp *Phase) PruneDeadNeurons() []int {
+	defer bp.mutationCheckpoint("PruneDeadNeurons", nil)()
+
+	alive := bp.backwardReachable()
+	pruned := []int{}
+	for _, id := range bp.hiddenNeuronIDs() {
+		if !alive[id] {
+			bp.removeNeuron(id)
+			pruned = append(pruned, id)
+		}
+	}
+	if bp.Debug && len(pruned) > 0 {
+		fmt.Printf("Pruned %d dead neurons: %v\n", len(pruned), pruned)
+	}
+	return pruned
+}
+
+// hiddenNeuronIDs returns the IDs of neurons that are neither inputs nor outputs, ascending.
+func (bp *Phase) hiddenNeuronIDs() []int {
+	hidden := []int{}
+	for _, id := range bp.sortedNeuronIDs() {
+		if !contains(bp.InputNodes, id) && !contains(bp.OutputNodes, id) {
+			hidden = append(hidden, id)
+		}
+	}
+	return hidden
+}
+
+// removeNeuron deletes a hidden neuron and every connection from it.
+func (bp *Phase) removeNeuron(id int) bool {
+	if _, exists := bp.Neurons[id]; !exists || contains(bp.InputNodes, id) || contains(bp.OutputNodes, id) {
+		return false
+	}
+	delete(bp.Neurons, id)
+	for _, neuron := range bp.Neurons {
+		for i := len(neuron.Connections) - 1; i >= 0; i-- {
+			if neuron.Connections[i].Source == id {
+				removeConnectionAt(neuron, i)
+			}
+		}
 	}
+	for i, trainable := range bp.TrainableNeurons {
+		if trainable == id {
+			bp.TrainableNeurons = append(bp.TrainableNeurons[:i], bp.TrainableNeurons[i+1:]...)
+			break
+		}
+	}
+	return true
 }
 
 // AdjustWeights modifies the weights of a random neuron's connections.
 func (bp *Phase) AdjustWeights() {
-	neuronIDs := bp.getAllNeuronIDs()
+	bp.adjustWeights(defaultWeightStep, bp.rng())
+}
+
+// adjustWeights perturbs the weights of a random neuron by Gaussian noise with standard
+// deviation sigma and returns the neuron's ID, or -1 when nothing changed.
+func (bp *Phase) adjustWeights(sigma float64, rng *rand.Rand) int {
+	defer bp.mutationCheckpoint("AdjustWeights", map[string]interface{}{"sigma": sigma})()
+
+	neuronIDs := bp.sortedNeuronIDs()
 	if len(neuronIDs) == 0 {
-		return
+		return -1
 	}
-	neuronID := neuronIDs[rand.Intn(len(neuronIDs))]
+	neuronID := neuronIDs[rng.Intn(len(neuronIDs))]
 	neuron := bp.Neurons[neuronID]
 	if len(neuron.Connections) == 0 {
-		return
+		return -1
 	}
 	for i := range neuron.Connections {
-		adjustment := rand.NormFloat64() * 0.05
-		neuron.Connections[i][1] += adjustment
+		adjustment := rng.NormFloat64() * sigma
+		neuron.Connections[i].Weight += adjustment
 	}
 	if bp.Debug {
 		fmt.Printf("Adjusted weights for Neuron %d\n", neuronID)
 	}
+	return neuronID
 }
 
 // AdjustBiases modifies the bias of a random neuron.
 func (bp *Phase) AdjustBiases() {
-	neuronIDs := bp.getAllNeuronIDs()
+	bp.adjustBiases(defaultBiasStep, bp.rng())
+}
+
+// adjustBiases perturbs the bias of a random neuron by Gaussian noise with standard deviation
+// sigma and returns the neuron's ID, or -1 when the network is empty.
+func (bp *Phase) adjustBiases(sigma float64, rng *rand.Rand) int {
+	defer bp.mutationCheckpoint("AdjustBiases", map[string]interface{}{"sigma": sigma})()
+
+	neuronIDs := bp.sortedNeuronIDs()
 	if len(neuronIDs) == 0 {
-		return
+		return -1
 	}
-	neuronID := neuronIDs[rand.Intn(len(neuronIDs))]
+	neuronID := neuronIDs[rng.Intn(len(neuronIDs))]
 	neuron := bp.Neurons[neuronID]
-	adjustment := rand.NormFloat64() * 0.05
+	adjustment := rng.NormFloat64() * sigma
 	neuron.Bias += adjustment
 	if bp.Debug {
 		fmt.Printf("Adjusted bias for Neuron %d by %f\n", neuronID, adjustment)
 	}
+	return neuronID
 }
 
 // ChangeActivationFunction changes the activation function of a random non-output neuron.
 func (bp *Phase) ChangeActivationFunction() {
+	bp.changeActivationFunction(bp.rng())
+}
+
+// changeActivationFunction changes a random non-output neuron's activation, drawing from rng,
+// and returns the neuron's ID, or -1 when nothing changed.
+func (bp *Phase) changeActivationFunction(rng *rand.Rand) int {
+	defer bp.mutationCheckpoint("ChangeActivationFunction", nil)()
+
 	nonOutputNeurons := []int{}
-	for id := range bp.Neurons {
+	for _, id := range bp.sortedNeuronIDs() {
 		if !contains(bp.OutputNodes, id) {
 			nonOutputNeurons = append(nonOutputNeurons, id)
 		}
 	}
 	if len(nonOutputNeurons) == 0 {
-		return
+		return -1
 	}
-	neuronID := nonOutputNeurons[rand.Intn(len(nonOutputNeurons))]
+	neuronID := nonOutputNeurons[rng.Intn(len(nonOutputNeurons))]
 	neuron := bp.Neurons[neuronID]
 	possibleActivations := []string{"relu", "sigmoid", "tanh", "leaky_relu", "elu", "linear"}
-	newAct := possibleActivations[rand.Intn(len(possibleActivations))]
+	newAct := possibleActivations[rng.Intn(len(possibleActivations))]
+	if newAct == neuron.Activation {
+		return -1
+	}
 	neuron.Activation = newAct
 	if bp.Debug {
 		fmt.Printf("Changed activation function of Neuron %d to %s\n", neuronID, newAct)
 	}
+	return neuronID
 }
 
 // AdjustAllWeights adjusts all connection weights by the specified amount.
 func (bp *Phase) AdjustAllWeights(adjustment float64) {
+	defer bp.mutationCheckpoint("AdjustAllWeights", map[string]interface{}{"adjustment": adjustment})()
+
 	for _, neuron := range bp.Neurons {
 		for i := range neuron.Connections {
-			neuron.Connections[i][1] += adjustment
+			neuron.Connections[i].Weight += adjustment
 		}
 	}
 	if bp.Debug {
@@ -246,6 +412,8 @@ func (bp *Phase) AdjustAllWeights(adjustment float64) {
 
 // AdjustAllBiases adjusts all biases by the specified amount.
 func (bp *Phase) AdjustAllBiases(adjustment float64) {
+	defer bp.mutationCheckpoint("AdjustAllBiases", map[string]interface{}{"adjustment": adjustment})()
+
 	for _, neuron := range bp.Neurons {
 		neuron.Bias += adjustment
 	}
@@ -255,7 +423,7 @@ func (bp *Phase) AdjustAllBiases(adjustment float64) {
 }
 
 // changeNeuronTypeTo changes the type of the neuron with the given ID to newType.
-func (bp *Phase) changeNeuronTypeTo(neuronID int, newType string) {
+func (bp *Phase) changeNeuronTypeTo(neuronID int, newType string, rng *rand.Rand) {
 	neuron, exists := bp.Neurons[neuronID]
 	if !exists || neuron.Type == "input" {
 		return
@@ -275,23 +443,24 @@ func (bp *Phase) changeNeuronTypeTo(neuronID int, newType string) {
 	case "lstm":
 		conCount := len(neuron.Connections)
 		neuron.GateWeights = map[string][]float64{
-			"input":  bp.RandomWeights(conCount),
-			"forget": bp.RandomWeights(conCount),
-			"output": bp.RandomWeights(conCount),
-			"cell":   bp.RandomWeights(conCount),
+			"input":  randomWeights(conCount, rng),
+			"forget": randomWeights(conCount, rng),
+			"output": randomWeights(conCount, rng),
+			"cell":   randomWeights(conCount, rng),
 		}
 		neuron.CellState = 0
 	case "cnn":
 		neuron.Kernels = [][]float64{
-			{rand.Float64(), rand.Float64()},
-			{rand.Float64(), rand.Float64()},
+			{rng.Float64(), rng.Float64()},
+			{rng.Float64(), rng.Float64()},
 		}
 	case "batch_norm":
 		neuron.BatchNormParams = &BatchNormParams{
-			Gamma: 1.0,
-			Beta:  0.0,
-			Mean:  0.0,
-			Var:   1.0,
+			Gamma:     1.0,
+			Beta:      0.0,
+			Mean:      0.0,
+			Var:       1.0,
+			SumInputs: true,
 		}
 	case "dropout":
 		neuron.DropoutRate = 0.5
@@ -303,10 +472,11 @@ func (bp *Phase) changeNeuronTypeTo(neuronID int, newType string) {
 }
 
 // changeNeuronType changes the type of the neuron with the given ID to a random type different from its current type.
-func (bp *Phase) changeNeuronType(neuronID int) {
+// It reports whether the type changed.
+func (bp *Phase) changeNeuronType(neuronID int, rng *rand.Rand) bool {
 	neuron, exists := bp.Neurons[neuronID]
 	if !exists || neuron.Type == "input" {
-		return
+		return false
 	}
 	currentType := neuron.Type
 	var possibleTypes []string
@@ -316,34 +486,54 @@ func (bp *Phase) changeNeuronType(neuronID int) {
 		}
 	}
 	if len(possibleTypes) == 0 {
-		return
+		return false
 	}
-	newType := possibleTypes[rand.Intn(len(possibleTypes))]
-	bp.changeNeuronTypeTo(neuronID, newType)
+	newType := possibleTypes[rng.Intn(len(possibleTypes))]
+	bp.changeNeuronTypeTo(neuronID, newType, rng)
+	return true
 }
 
 // ChangeSingleNeuronType randomly selects one non-input neuron and changes its type to a different random type.
 func (bp *Phase) ChangeSingleNeuronType() {
+	bp.changeSingleNeuronType(bp.rng())
+}
+
+// changeSingleNeuronType changes a random non-input neuron's type, drawing from rng, and
+// returns the neuron's ID, or -1 when nothing changed.
+func (bp *Phase) changeSingleNeuronType(rng *rand.Rand) int {
+	defer bp.mutationCheckpoint("ChangeSingleNeuronType", nil)()
+
 	nonInputNeurons := bp.getNonInputNeuronIDs()
 	if len(nonInputNeurons) == 0 {
 		if bp.Debug {
 			fmt.Println("No non-input neurons available to change.")
 		}
-		return
+		return -1
+	}
+	neuronID := nonInputNeurons[rng.Intn(len(nonInputNeurons))]
+	if !bp.changeNeuronType(neuronID, rng) {
+		return -1
 	}
-	neuronID := nonInputNeurons[rand.Intn(len(nonInputNeurons))]
-	bp.changeNeuronType(neuronID)
+	return neuronID
 }
 
 // ChangePercentageOfNeuronsTypes changes the types of a specified percentage of non-input neurons to random types.
 func (bp *Phase) ChangePercentageOfNeuronsTypes(percentage float64) {
+	bp.changePercentageOfNeuronsTypes(percentage, bp.rng())
+}
+
+// changePercentageOfNeuronsTypes changes the types of percentage% of the non-input neurons,
+// drawing from rng, and returns how many changed.
+func (bp *Phase) changePercentageOfNeuronsTypes(percentage float64, rng *rand.Rand) int {
+	defer bp.mutationCheckpoint("ChangePercentageOfNeuronsTypes", map[string]interface{}{"percentage": percentage})()
+
 	nonInputNeurons := bp.getNonInputNeuronIDs()
 	total := len(nonInputNeurons)
 	if total == 0 {
 		if bp.Debug {
 			fmt.Println("No non-input neurons available to change.")
 		}
-		return
+		return 0
 	}
 	if percentage < 0 {
 		percentage = 0
@@ -354,16 +544,22 @@ func (bp *Phase) ChangePercentageOfNeuronsTypes(percentage float64) {
 	if numToChange < 1 && percentage > 0 {
 		numToChange = 1
 	}
-	rand.Shuffle(len(nonInputNeurons), func(i, j int) {
+	rng.Shuffle(len(nonInputNeurons), func(i, j int) {
 		nonInputNeurons[i], nonInputNeurons[j] = nonInputNeurons[j], nonInputNeurons[i]
 	})
+	changed := 0
 	for i := 0; i < numToChange && i < total; i++ {
-		bp.changeNeuronType(nonInputNeurons[i])
+		if bp.changeNeuronType(nonInputNeurons[i], rng) {
+			changed++
+		}
 	}
+	return changed
 }
 
 // RandomizeAllNeuronsTypes changes all non-input neurons to random types different from their current types.
 func (bp *Phase) RandomizeAllNeuronsTypes() {
+	defer bp.mutationCheckpoint("RandomizeAllNeuronsTypes", nil)()
+
 	nonInputNeurons := bp.getNonInputNeuronIDs()
 	if len(nonInputNeurons) == 0 {
 		if bp.Debug {
@@ -372,11 +568,13 @@ func (bp *Phase) RandomizeAllNeuronsTypes() {
 		return
 	}
 	for _, id := range nonInputNeurons {
-		bp.changeNeuronType(id)
+		bp.changeNeuronType(id, bp.rng())
 	}
 }
 
 func (bp *Phase) SetAllNeuronsToSameRandomType() {
+	defer bp.mutationCheckpoint("SetAllNeuronsToSameRandomType", nil)()
+
 	nonInputNeurons := bp.getNonInputNeuronIDs()
 	if len(nonInputNeurons) == 0 {
 		if bp.Debug {
@@ -384,9 +582,9 @@ func (bp *Phase) SetAllNeuronsToSameRandomType() {
 		}
 		return
 	}
-	newType := neuronTypes[rand.Intn(len(neuronTypes))]
+	newType := neuronTypes[bp.rng().Intn(len(neuronTypes))]
 	for _, id := range nonInputNeurons {
-		bp.changeNeuronTypeTo(id, newType)
+		bp.changeNeuronTypeTo(id, newType, bp.rng())
 	}
 	if bp.Debug {
 		fmt.Printf("Set all non-input neurons to type %s\n", newType)
@@ -0,0 +1,132 @@
+package phase
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+)
+
+// WidenLayer grows the inferred hidden layer at layerIdx (see InferLayers) to newSize neurons
+// the Net2Net way (Net2WiderNet). Each extra neuron duplicates a random neuron of the layer,
+// with the same type, bias and incoming weights, and every duplicated neuron's outgoing
+// weights are shared evenly between it and its copies, so the network computes the same
+// function. The copies start out identical; mutations or noisy training tell them apart.
+//
+// Only neurons whose consumers all sum their weighted inputs (dense, RNN, LSTM and BatchNorm
+// neurons) are duplicated, as splitting a weight changes what CNN and attention neurons compute.
+// Dropout neurons are never duplicated either. It returns the IDs of the new neurons, or nil
+// when layerIdx is not a hidden layer, the layer already has newSize neurons or none of its
+// neurons can be duplicated.
+func (bp *Phase) WidenLayer(layerIdx, newSize int) []int {
+	defer bp.mutationCheckpoint("WidenLayer", map[string]interface{}{"layer": layerIdx, "size": newSize})()
+	return bp.widenLayer(layerIdx, newSize, bp.rng())
+}
+
+// widenLayer is WidenLayer drawing the neurons to duplicate from rng.
+func (bp *Phase) widenLayer(layerIdx, newSize int, rng *rand.Rand) []int {
+	layers := bp.InferLayers()
+	if layerIdx <= 0 || layerIdx >= len(layers)-1 || newSize <= len(layers[layerIdx]) {
+		return nil
+	}
+	eligible := []int{}
+	for _, id := range layers[layerIdx] {
+		if bp.duplicable(id) {
+			eligible = append(eligible, id)
+		}
+	}
+	if len(eligible) == 0 {
+		return nil
+	}
+
+	copies := make(map[int][]int, len(eligible))
+	added := []int{}
+	for size := len(layers[layerIdx]); size < newSize; size++ {
+		source := eligible[rng.Intn(len(eligible))]
+		duplicate := bp.duplicateNeuron(source)
+		copies[source] = append(copies[source], duplicate.ID)
+		added = append(added, duplicate.ID)
+	}
+
+	// Split outgoing weights only once every copy exists, so copies that feed each other, or
+	// themselves through recurrence, are split like any other consumer.
+	targets := bp.sortedNeuronIDs()
+	for _, source := range eligible {
+		if len(copies[source]) == 0 {
+			continue
+		}
+		share := 1 / float64(len(copies[source])+1)
+		for _, targetID := range targets {
+			target := bp.Neurons[targetID]
+			for i, n := 0, len(target.Connections); i < n; i++ {
+				if conn := target.Connections[i]; conn.Source != source || !conn.Enabled {
+					continue
+				}
+				target.Connections[i].Weight *= share
+				for _, duplicate := range copies[source] {
+					copyConnection(target, i, duplicate, target.Connections[i].Weight)
+				}
+			}
+		}
+	}
+	if bp.Debug {
+		fmt.Printf("Widened layer %d from %d to %d neurons\n", layerIdx, len(layers[layerIdx]), newSize)
+	}
+	return added
+}
+
+// duplicable reports whether WidenLayer can copy the neuron without changing the function: it
+// is not a dropout, attention, input or output neuron, and every neuron it feeds through an
+// enabled connection sums its weighted inputs.
+func (bp *Phase) duplicable(id int) bool {
+	neuron := bp.Neurons[id]
+	switch neuron.Type {
+	case "input", "dropout", "attention":
+		return false
+	}
+	if contains(bp.OutputNodes, id) {
+		return false
+	}
+	for _, target := range bp.Neurons {
+		if !readsInputsByPosition(target) {
+			continue
+		}
+		for _, conn := range target.Connections {
+			if conn.Source == id && conn.Enabled {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// duplicateNeuron adds a deep copy of the neuron under a new ID, with its own innovation
+// numbers for the copied incoming connections.
+func (bp *Phase) duplicateNeuron(id int) *Neuron {
+	data, err := json.Marshal(bp.Neurons[id])
+	if err != nil {
+		panic(fmt.Sprintf("failed to serialize Neuron %d for duplication: %v", id, err))
+	}
+	duplicate := &Neuron{}
+	if err := json.Unmarshal(data, duplicate); err != nil {
+		panic(fmt.Sprintf("failed to deserialize Neuron %d for duplication: %v", id, err))
+	}
+	duplicate.ID = Innovations.NodeID(bp.GetNextNeuronID())
+	for i, conn := range duplicate.Connections {
+		tracked := newTrackedConnection(conn.Source, duplicate.ID, conn.Weight)
+		tracked.Enabled = conn.Enabled
+		duplicate.Connections[i] = tracked
+	}
+	duplicate.IsNew = true
+	bp.Neurons[duplicate.ID] = duplicate
+	return duplicate
+}
+
+// DeepenAt inserts an identity-initialized layer right after the inferred layer at layerIdx
+// (Net2DeeperNet), using InsertHiddenLayer's linear relays, so the network computes the same
+// function. Connections into cnn and attention neurons are rewritten in place, so their inputs
+// keep their positions. It returns the IDs of the new neurons, or nil when layerIdx is not followed by
+// another layer.
+func (bp *Phase) DeepenAt(layerIdx int) []int {
+	defer bp.mutationCheckpoint("DeepenAt", map[string]interface{}{"layer": layerIdx})()
+	return bp.insertHiddenLayer(layerIdx)
+}
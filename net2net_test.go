@@ -0,0 +1,201 @@
+package phase
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// net2netTolerance bounds the rounding error of splitting weights and adding linear relays.
+const net2netTolerance = 1e-9
+
+// net2netInputs are the samples the outputs are compared on.
+var net2netInputs = [][]float64{
+	{0.3, -0.6, 0.9},
+	{-1.2, 0.4, 0.05},
+	{0.8, 0.8, -0.7},
+}
+
+// forwardOutputs runs every sample through bp and returns the outputs in OutputNodes order.
+func forwardOutputs(bp *Phase, timesteps int) [][]float64 {
+	outputs := make([][]float64, len(net2netInputs))
+	for s, sample := range net2netInputs {
+		inputs := make(map[int]float64, len(sample))
+		for i, id := range bp.InputNodes {
+			inputs[id] = sample[i]
+		}
+		bp.Forward(inputs, timesteps)
+		for _, id := range bp.OutputNodes {
+			outputs[s] = append(outputs[s], bp.Neurons[id].Value)
+		}
+	}
+	return outputs
+}
+
+// assertSameOutputs fails when any output differs by more than net2netTolerance.
+func assertSameOutputs(t *testing.T, before, after [][]float64) {
+	t.Helper()
+	for s := range before {
+		for i := range before[s] {
+			if diff := math.Abs(before[s][i] - after[s][i]); diff > net2netTolerance {
+				t.Fatalf("sample %d output %d changed by %g: %v -> %v", s, i, diff, before[s][i], after[s][i])
+			}
+		}
+	}
+}
+
+// mixedPhase returns a 3-4-3-2 network whose second hidden layer holds an RNN, an LSTM and a
+// CNN neuron. The CNN reads only the first two neurons of the first hidden layer, so the
+// other two can still be duplicated.
+func mixedPhase() *Phase {
+	bp := NewPhaseWithLayers([]int{3, 4, 3, 2}, "tanh", "sigmoid")
+
+	rnn := bp.Neurons[7]
+	rnn.Type = "rnn"
+
+	lstm := bp.Neurons[8]
+	lstm.Type = "lstm"
+	lstm.GateWeights = map[string][]float64{
+		"input":  {0.4, -0.2, 0.7, 0.1},
+		"forget": {0.3, 0.5, -0.6, 0.2},
+		"output": {-0.1, 0.6, 0.2, 0.8},
+		"cell":   {0.9, -0.4, 0.3, -0.5},
+	}
+
+	cnn := bp.Neurons[9]
+	cnn.Type = "cnn"
+	cnn.Activation = "relu"
+	cnn.Connections = cnn.Connections[:2]
+	cnn.Kernels = [][]float64{{0.5, -0.3}, {0.2, 0.7}}
+	return bp
+}
+
+func TestWidenLayerPreservesOutputs(t *testing.T) {
+	for _, act := range []string{"relu", "tanh", "sigmoid"} {
+		for layer := 1; layer <= 2; layer++ {
+			t.Run(fmt.Sprintf("%s/layer%d", act, layer), func(t *testing.T) {
+				bp := NewPhaseWithLayers([]int{3, 4, 3, 2}, act, "sigmoid")
+				size := len(bp.InferLayers()[layer])
+				before := forwardOutputs(bp, 1)
+				if added := bp.WidenLayer(layer, 7); len(added) != 7-size {
+					t.Fatalf("WidenLayer(%d, 7) added %d neurons, want %d", layer, len(added), 7-size)
+				}
+				if got := len(bp.InferLayers()[layer]); got != 7 {
+					t.Fatalf("layer %d has %d neurons, want 7", layer, got)
+				}
+				assertSameOutputs(t, before, forwardOutputs(bp, 1))
+			})
+		}
+	}
+}
+
+func TestWidenLayerPreservesMixedOutputs(t *testing.T) {
+	for _, timesteps := range []int{1, 3} {
+		t.Run(fmt.Sprintf("timesteps%d", timesteps), func(t *testing.T) {
+			bp := mixedPhase()
+			before := forwardOutputs(bp, timesteps)
+
+			added := bp.WidenLayer(1, 8)
+			if len(added) != 4 {
+				t.Fatalf("WidenLayer(1, 8) added %d neurons, want 4", len(added))
+			}
+			for _, id := range added {
+				source := bp.Neurons[id].Connections[0].Source
+				if source < 0 || source > 2 {
+					t.Fatalf("new neuron %d is not fed by the inputs", id)
+				}
+			}
+			assertSameOutputs(t, before, forwardOutputs(bp, timesteps))
+
+			before = forwardOutputs(bp, timesteps)
+			if added := bp.WidenLayer(2, 5); len(added) != 2 {
+				t.Fatalf("WidenLayer(2, 5) added %d neurons, want 2", len(added))
+			}
+			assertSameOutputs(t, before, forwardOutputs(bp, timesteps))
+		})
+	}
+}
+
+func TestWidenLayerSkipsCNNFeeders(t *testing.T) {
+	bp := mixedPhase()
+	for _, id := range bp.WidenLayer(1, 12) {
+		for _, conn := range bp.Neurons[9].Connections {
+			if conn.Source == id {
+				t.Fatalf("new neuron %d feeds the CNN neuron", id)
+			}
+		}
+	}
+	for _, conn := range bp.Neurons[9].Connections {
+		if conn.Source != 3 && conn.Source != 4 {
+			t.Fatalf("CNN neuron gained an input from %d", conn.Source)
+		}
+	}
+}
+
+func TestWidenLayerRejectsInvalidLayers(t *testing.T) {
+	bp := NewPhaseWithLayers([]int{3, 4, 2}, "relu", "sigmoid")
+	for _, layer := range []int{0, 2, 5} {
+		if added := bp.WidenLayer(layer, 10); added != nil {
+			t.Errorf("WidenLayer(%d, 10) added %v", layer, added)
+		}
+	}
+	if added := bp.WidenLayer(1, 4); added != nil {
+		t.Errorf("WidenLayer(1, 4) on a 4-neuron layer added %v", added)
+	}
+}
+
+func TestDeepenAtPreservesOutputs(t *testing.T) {
+	for _, act := range []string{"relu", "tanh", "sigmoid"} {
+		for layer := 0; layer <= 2; layer++ {
+			t.Run(fmt.Sprintf("%s/layer%d", act, layer), func(t *testing.T) {
+				bp := NewPhaseWithLayers([]int{3, 4, 3, 2}, act, "sigmoid")
+				layers := len(bp.InferLayers())
+				before := forwardOutputs(bp, 1)
+				if added := bp.DeepenAt(layer); len(added) == 0 {
+					t.Fatalf("DeepenAt(%d) added no neurons", layer)
+				}
+				if got := len(bp.InferLayers()); got != layers+1 {
+					t.Fatalf("network has %d layers, want %d", got, layers+1)
+				}
+				assertSameOutputs(t, before, forwardOutputs(bp, 1))
+			})
+		}
+	}
+}
+
+func TestDeepenAtPreservesMixedOutputs(t *testing.T) {
+	for _, timesteps := range []int{1, 3} {
+		for layer := 0; layer <= 2; layer++ {
+			t.Run(fmt.Sprintf("timesteps%d/layer%d", timesteps, layer), func(t *testing.T) {
+				bp := mixedPhase()
+				before := forwardOutputs(bp, timesteps)
+				if added := bp.DeepenAt(layer); len(added) == 0 {
+					t.Fatalf("DeepenAt(%d) added no neurons", layer)
+				}
+				assertSameOutputs(t, before, forwardOutputs(bp, timesteps))
+			})
+		}
+	}
+}
+
+func TestDeepenAtKeepsCNNInputPositions(t *testing.T) {
+	bp := mixedPhase()
+	bp.DeepenAt(1)
+	cnn := bp.Neurons[9]
+	if len(cnn.Connections) != 2 {
+		t.Fatalf("CNN neuron has %d connections, want 2", len(cnn.Connections))
+	}
+	for i, conn := range cnn.Connections {
+		relay := bp.Neurons[conn.Source]
+		if !conn.Enabled || relay.Activation != "linear" || relay.Connections[0].Source != 3+i {
+			t.Fatalf("CNN input %d is not a relay of neuron %d", i, 3+i)
+		}
+	}
+}
+
+func TestDeepenAtRejectsLastLayer(t *testing.T) {
+	bp := NewPhaseWithLayers([]int{3, 4, 2}, "relu", "sigmoid")
+	if added := bp.DeepenAt(2); added != nil {
+		t.Errorf("DeepenAt(2) added %v", added)
+	}
+}
@@ -2,7 +2,6 @@ package phase
 
 import (
 	"fmt"
-	"math/rand"
 )
 
 // NewPhaseWithLayers creates a strictly feed-forward network
@@ -40,14 +39,14 @@ func NewPhaseWithLayers(layers []int, hiddenAct, outputAct string) *Phase {
 				ID:         neuronID,
 				Type:       "dense",
 				Activation: act,
-				Bias:       rand.Float64()*0.1 - 0.05, // small random bias
+				Bias:       bp.rng().Float64()*0.1 - 0.05, // small random bias
 			}
 			// Only forward connections from the previous layer *to* this neuron
 			for srcID := prevLayerStart; srcID < prevLayerEnd; srcID++ {
-				w := rand.Float64()*2 - 1
+				w := bp.rng().Float64()*2 - 1
 				bp.Neurons[neuronID].Connections = append(
 					bp.Neurons[neuronID].Connections,
-					[]float64{float64(srcID), w},
+					NewConnection(srcID, w),
 				)
 			}
 
@@ -114,14 +113,14 @@ func (bp *Phase) InitializeWithLayers(layers []int, hiddenAct, outputAct string)
 				ID:         neuronID,
 				Type:       "dense",
 				Activation: act,
-				Bias:       rand.Float64()*0.1 - 0.05,
+				Bias:       bp.rng().Float64()*0.1 - 0.05,
 			}
 			// Add forward connections from previous layer
 			for srcID := prevLayerStart; srcID < prevLayerEnd; srcID++ {
-				w := rand.Float64()*2 - 1
+				w := bp.rng().Float64()*2 - 1
 				bp.Neurons[neuronID].Connections = append(
 					bp.Neurons[neuronID].Connections,
-					[]float64{float64(srcID), w},
+					NewConnection(srcID, w),
 				)
 			}
 
@@ -146,8 +145,44 @@ func (bp *Phase) InitializeWithLayers(layers []int, hiddenAct, outputAct string)
 	}
 }
 
-// Copy creates a deep copy of the Phase instance.
+// Copy creates a deep copy of the Phase instance. When bp has a Rand, the copy's Rand seed
+// and ID are derived from the seed of bp.Rand and the number of earlier copies, without
+// drawing from bp.Rand, so copying does not change bp's later mutations. If bp.Rand was
+// assigned directly rather than by NewPhaseWithSeed, the first copy draws that seed from it.
+// Several goroutines may copy the same Phase at once.
 func (bp *Phase) Copy() *Phase {
+	if bp.Genealogy != nil {
+		bp.ensureModelID(bp.Genealogy) // The copy is the same model until it is changed
+	}
+	newBP := bp.clone()
+	if bp.Rand != nil {
+		// The copy gets its own Rand, so copies can be used concurrently.
+		copyRandMu.Lock()
+		seed, id := bp.copySeed()
+		copyRandMu.Unlock()
+		newBP.seedRand(seed)
+		newBP.ID = id
+	} else {
+		newBP.ID = bp.GetNextPhaseID() // Assign a new unique ID
+	}
+	return newBP
+}
+
+// workerCopy returns a copy of bp for worker w of a parallel evaluation. It keeps bp's ID and
+// seeds its Rand, if bp has one, with w. Unlike Copy it leaves bp's copy count alone, so the
+// number of workers, which follows the machine's core count, cannot change later copies.
+func (bp *Phase) workerCopy(w int) *Phase {
+	newBP := bp.clone()
+	newBP.ID = bp.ID
+	if bp.Rand != nil {
+		newBP.seedRand(int64(w))
+	}
+	return newBP
+}
+
+// clone deep-copies bp through JSON and carries over the fields JSON leaves out, except the
+// ID and Rand, which Copy and workerCopy assign.
+func (bp *Phase) clone() *Phase {
 	data, err := bp.SerializeToJSON()
 	if err != nil {
 		panic(fmt.Sprintf("failed to serialize Phase for copying: %v", err))
@@ -157,12 +192,14 @@ func (bp *Phase) Copy() *Phase {
 	if err != nil {
 		panic(fmt.Sprintf("failed to deserialize Phase for copying: %v", err))
 	}
-	newBP.ID = bp.GetNextPhaseID() // Assign a new unique ID
+	newBP.Strict = bp.Strict
+	newBP.ImprovementPolicy = bp.ImprovementPolicy
+	newBP.Genealogy = bp.Genealogy
 	return newBP
 }
 
 // GetNextPhaseID generates a unique ID for new Phase instances (simple increment for this example)
 func (bp *Phase) GetNextPhaseID() int {
 	// This is a simple implementation; in a real scenario, track globally or use a counter
-	return rand.Intn(10000) + 1 // Random ID for demo; replace with proper counter if needed
+	return bp.rng().Intn(10000) + 1 // Random ID for demo; replace with proper counter if needed
 }
@@ -3,7 +3,6 @@ package phase
 import (
 	"fmt"
 	"math"
-	"math/rand"
 )
 
 // BatchNormParams holds parameters for batch normalization
@@ -12,6 +11,10 @@ type BatchNormParams struct {
 	Beta  float64 `json:"beta"`
 	Mean  float64 `json:"mean"`
 	Var   float64 `json:"var"`
+	// SumInputs makes the neuron normalize the weighted sum of its inputs (see
+	// ProcessBatchNormNeuron). New BatchNorm neurons set it; models saved without it keep
+	// normalizing the neuron's previous value, ignoring the inputs, as they always did.
+	SumInputs bool `json:"sum_inputs,omitempty"`
 }
 
 // Neuron represents a single neuron in the network
@@ -20,7 +23,7 @@ type Neuron struct {
 	Type             string           `json:"type"`              // Dense, RNN, LSTM, CNN, etc.
 	Value            float64          `json:"value"`             // Current value
 	Bias             float64          `json:"bias"`              // Default: 0.0
-	Connections      [][]float64      `json:"connections"`       // [source_id, weight]
+	Connections      []Connection     `json:"connections"`       // Incoming connections, serialized as [source_id, weight] pairs
 	Activation       string           `json:"activation"`        // Activation function
 	LoopCount        int              `json:"loop_count"`        // For RNN/LSTM loops
 	WindowSize       int              `json:"window_size"`       // For CNN
@@ -60,7 +63,11 @@ func (bp *Phase) ProcessNeuron(neuron *Neuron, inputs []float64, timestep int) {
 	case "dropout":
 		bp.ApplyDropout(neuron)
 	case "batch_norm":
-		bp.ApplyBatchNormalization(neuron, 0.0, 1.0) // Example mean/variance
+		if neuron.BatchNormParams != nil && neuron.BatchNormParams.SumInputs {
+			bp.ProcessBatchNormNeuron(neuron, inputs)
+		} else {
+			bp.ApplyBatchNormalization(neuron, 0.0, 1.0) // Legacy: normalizes the previous value
+		}
 	case "attention":
 		// Handled separately in Forward method
 		if bp.Debug {
@@ -219,7 +226,7 @@ func (bp *Phase) ProcessCNNNeuron(neuron *Neuron, inputs []float64) {
 
 // ApplyDropout randomly zeroes out a neuron's value
 func (bp *Phase) ApplyDropout(neuron *Neuron) {
-	if rand.Float64() < neuron.DropoutRate {
+	if bp.rng().Float64() < neuron.DropoutRate {
 		neuron.Value = 0
 		if bp.Debug {
 			fmt.Printf("Dropout Neuron %d: Value set to 0\n", neuron.ID)
@@ -231,6 +238,19 @@ func (bp *Phase) ApplyDropout(neuron *Neuron) {
 	}
 }
 
+// ProcessBatchNormNeuron sums the weighted inputs and the bias, normalizes the sum with the
+// neuron's BatchNormParams and applies its activation. ProcessNeuron uses it only when
+// BatchNormParams.SumInputs is set.
+func (bp *Phase) ProcessBatchNormNeuron(neuron *Neuron, inputs []float64) {
+	sum := neuron.Bias
+	for _, input := range inputs {
+		sum += input
+	}
+	neuron.Value = sum
+	bp.ApplyBatchNormalization(neuron, 0.0, 1.0)
+	neuron.Value = bp.ApplyScalarActivation(neuron.Value, neuron.Activation)
+}
+
 // ApplyBatchNormalization normalizes the neuron's value
 func (bp *Phase) ApplyBatchNormalization(neuron *Neuron, mean, variance float64) {
 	if neuron.BatchNormParams == nil {
@@ -345,7 +365,7 @@ func (bp *Phase) ProcessNCANeuron(neuron *Neuron) {
 func (bp *Phase) InitializeKernel(kernelSize int) []float64 {
 	kernel := make([]float64, kernelSize)
 	for i := range kernel {
-		kernel[i] = rand.Float64() // Initialize with random weights between 0 and 1
+		kernel[i] = bp.rng().Float64() // Initialize with random weights between 0 and 1
 	}
 	return kernel
 }
@@ -0,0 +1,181 @@
+package phase
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Behavior characterizes what a Phase does, independently of how well it does it.
+// The standard characterization is the concatenated output vectors on a probe set.
+type Behavior []float64
+
+// Distance returns the root-mean-square difference between two behaviors, so values stay
+// comparable across probe sets of different sizes. Behaviors of different length are
+// compared on their common prefix.
+func (b Behavior) Distance(other Behavior) float64 {
+	n := len(b)
+	if len(other) < n {
+		n = len(other)
+	}
+	if n == 0 {
+		return 0
+	}
+	sum := 0.0
+	for i := 0; i < n; i++ {
+		d := b[i] - other[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum / float64(n))
+}
+
+// BehaviorFromCheckpoints runs the probe checkpoints through the layers after the
+// checkpointed pre-output neurons, the same way EvaluateWithCheckpoints does, and concatenates
+// the output values in OutputNodes order. A nil probe uses every checkpoint. When
+// checkpointFolder is set, checkpoints are loaded from disk by index instead.
+func (bp *Phase) BehaviorFromCheckpoints(checkpointFolder string, checkpoints []map[int]map[string]interface{}, probe []int) Behavior {
+	if probe == nil {
+		probe = make([]int, len(checkpoints))
+		for i := range probe {
+			probe[i] = i
+		}
+	}
+	behavior := make(Behavior, 0, len(probe)*len(bp.OutputNodes))
+	for _, idx := range probe {
+		var checkpoint map[int]map[string]interface{}
+		if checkpointFolder == "" {
+			if idx < 0 || idx >= len(checkpoints) {
+				continue
+			}
+			checkpoint = checkpoints[idx]
+		} else {
+			loaded, err := bp.LoadCheckpoint(checkpointFolder, idx)
+			if err != nil {
+				continue
+			}
+			checkpoint = loaded
+		}
+		outputs := bp.ComputePartialOutputsFromCheckpoint(checkpoint)
+		for _, outID := range bp.OutputNodes {
+			behavior = append(behavior, outputs[outID])
+		}
+	}
+	return behavior
+}
+
+// NoveltyArchive stores behaviors seen so far. It is safe for concurrent use, so several
+// Grow workers can share one archive.
+type NoveltyArchive struct {
+	mu        sync.Mutex
+	behaviors []Behavior
+	maxSize   int
+}
+
+// NewNoveltyArchive returns an empty archive holding at most maxSize behaviors
+// (0 means unbounded). When full, the oldest behavior is dropped.
+func NewNoveltyArchive(maxSize int) *NoveltyArchive {
+	return &NoveltyArchive{maxSize: maxSize}
+}
+
+// Add stores a copy of the behavior.
+func (a *NoveltyArchive) Add(b Behavior) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.behaviors = append(a.behaviors, append(Behavior{}, b...))
+	if a.maxSize > 0 && len(a.behaviors) > a.maxSize {
+		a.behaviors = a.behaviors[len(a.behaviors)-a.maxSize:]
+	}
+}
+
+// Len returns the number of archived behaviors.
+func (a *NoveltyArchive) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.behaviors)
+}
+
+// Novelty returns the mean distance from b to its k nearest archived behaviors.
+// One archived copy identical to b (b itself, once archived) is skipped. An empty archive
+// makes every behavior maximally novel and returns +Inf.
+func (a *NoveltyArchive) Novelty(b Behavior, k int) float64 {
+	a.mu.Lock()
+	distances := make([]float64, 0, len(a.behaviors))
+	skippedSelf := false
+	for _, other := range a.behaviors {
+		d := b.Distance(other)
+		if d == 0 && !skippedSelf {
+			skippedSelf = true
+			continue
+		}
+		distances = append(distances, d)
+	}
+	a.mu.Unlock()
+
+	if len(distances) == 0 {
+		return math.Inf(1)
+	}
+	sort.Float64s(distances)
+	if k < 1 || k > len(distances) {
+		k = len(distances)
+	}
+	sum := 0.0
+	for _, d := range distances[:k] {
+		sum += d
+	}
+	return sum / float64(k)
+}
+
+// NoveltyConfig enables novelty search in GrowWithConfig.
+type NoveltyConfig struct {
+	K             int             // Neighbors used for the novelty score (default 15)
+	Archive       *NoveltyArchive // Shared archive; nil gives each Grow call its own
+	Probe         []int           // Checkpoint indices used to characterize behavior; nil uses all
+	Weight        float64         // 0 = improvement only, 1 = novelty only; values between blend both
+	AddThreshold  float64         // Candidates at least this novel are archived (0 archives all)
+	MaxArchiveLen int             // Size limit of an archive created for this run (0 = unbounded)
+}
+
+// noveltyState holds what GrowWithConfig needs to score candidates by novelty.
+type noveltyState struct {
+	cfg              NoveltyConfig
+	checkpointFolder string
+	checkpoints      []map[int]map[string]interface{}
+	store            *NoveltyArchive
+}
+
+func newNoveltyState(cfg NoveltyConfig, checkpointFolder string, checkpoints []map[int]map[string]interface{}) *noveltyState {
+	if cfg.K < 1 {
+		cfg.K = 15
+	}
+	store := cfg.Archive
+	if store == nil {
+		store = NewNoveltyArchive(cfg.MaxArchiveLen)
+	}
+	return &noveltyState{cfg: cfg, checkpointFolder: checkpointFolder, checkpoints: checkpoints, store: store}
+}
+
+func (n *noveltyState) behavior(bp *Phase) Behavior {
+	return bp.BehaviorFromCheckpoints(n.checkpointFolder, n.checkpoints, n.cfg.Probe)
+}
+
+// score returns the novelty of b. Against an empty archive it returns 1 rather than +Inf,
+// so the first comparison does not swamp the blend.
+func (n *noveltyState) score(b Behavior) float64 {
+	novelty := n.store.Novelty(b, n.cfg.K)
+	if math.IsInf(novelty, 1) {
+		return 1
+	}
+	return novelty
+}
+
+// blend mixes the improvement with the gain in novelty over the incumbent.
+func (n *noveltyState) blend(improvement, noveltyGain float64) float64 {
+	return (1-n.cfg.Weight)*improvement + n.cfg.Weight*noveltyGain
+}
+
+// archive stores b if it is novel enough.
+func (n *noveltyState) archive(b Behavior, novelty float64) {
+	if novelty >= n.cfg.AddThreshold {
+		n.store.Add(b)
+	}
+}
@@ -0,0 +1,241 @@
+package phase
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Objective is one criterion of a multi-objective comparison between models.
+type Objective struct {
+	Name     string
+	Maximize bool                             // false means lower is better
+	Measure  func(result ModelResult) float64 // Called once per model
+}
+
+// ExactAccuracyObjective maximizes ModelResult.ExactAcc.
+func ExactAccuracyObjective() Objective {
+	return Objective{Name: "exact_acc", Maximize: true, Measure: func(r ModelResult) float64 {
+		return r.ExactAcc
+	}}
+}
+
+// ClosenessObjective maximizes the closeness quality of ModelResult.ClosenessBins.
+func ClosenessObjective() Objective {
+	return Objective{Name: "closeness_quality", Maximize: true, Measure: func(r ModelResult) float64 {
+		return r.BP.ComputeClosenessQuality(r.ClosenessBins)
+	}}
+}
+
+// ApproxScoreObjective maximizes ModelResult.ApproxScore.
+func ApproxScoreObjective() Objective {
+	return Objective{Name: "approx_score", Maximize: true, Measure: func(r ModelResult) float64 {
+		return r.ApproxScore
+	}}
+}
+
+// NeuronCountObjective minimizes the number of neurons.
+func NeuronCountObjective() Objective {
+	return Objective{Name: "neurons", Measure: func(r ModelResult) float64 {
+		return float64(len(r.BP.Neurons))
+	}}
+}
+
+// ConnectionCountObjective minimizes the number of enabled connections.
+func ConnectionCountObjective() Objective {
+	return Objective{Name: "connections", Measure: func(r ModelResult) float64 {
+		count := 0
+		for _, neuron := range r.BP.Neurons {
+			for _, conn := range neuron.Connections {
+				if conn.Enabled {
+					count++
+				}
+			}
+		}
+		return float64(count)
+	}}
+}
+
+// InferenceTimeObjective minimizes the mean wall-clock time of a Forward pass, in seconds,
+// measured over the given inputs. Forward overwrites neuron values, so results must not be
+// measured concurrently with other use of the same Phase.
+func InferenceTimeObjective(inputs []map[int]float64, timesteps int) Objective {
+	return Objective{Name: "inference_time", Measure: func(r ModelResult) float64 {
+		if len(inputs) == 0 {
+			return 0
+		}
+		start := time.Now()
+		for _, input := range inputs {
+			r.BP.Forward(input, timesteps)
+		}
+		return time.Since(start).Seconds() / float64(len(inputs))
+	}}
+}
+
+// DefaultObjectives returns the three accuracy metrics plus neuron and connection counts.
+func DefaultObjectives() []Objective {
+	return []Objective{
+		ExactAccuracyObjective(),
+		ClosenessObjective(),
+		ApproxScoreObjective(),
+		NeuronCountObjective(),
+		ConnectionCountObjective(),
+	}
+}
+
+// EvaluateObjectives measures every result on every objective. NaN scores are replaced by
+// the worst possible value for their objective.
+func EvaluateObjectives(results []ModelResult, objectives []Objective) [][]float64 {
+	scores := make([][]float64, len(results))
+	for i, result := range results {
+		scores[i] = make([]float64, len(objectives))
+		for j, obj := range objectives {
+			v := obj.Measure(result)
+			if math.IsNaN(v) {
+				v = math.Inf(1)
+				if obj.Maximize {
+					v = math.Inf(-1)
+				}
+			}
+			scores[i][j] = v
+		}
+	}
+	return scores
+}
+
+// dominates reports whether score a is at least as good as b on every objective and
+// strictly better on at least one.
+func dominates(a, b []float64, objectives []Objective) bool {
+	better := false
+	for j, obj := range objectives {
+		x, y := a[j], b[j]
+		if !obj.Maximize {
+			x, y = -x, -y
+		}
+		if x < y {
+			return false
+		}
+		if x > y {
+			better = true
+		}
+	}
+	return better
+}
+
+// NonDominatedSort splits scores into Pareto fronts (fast non-dominated sort from NSGA-II).
+// Front 0 holds the indices no other score dominates, front 1 those dominated only by front 0,
+// and so on. Indices within a front are ascending.
+func NonDominatedSort(scores [][]float64, objectives []Objective) [][]int {
+	n := len(scores)
+	dominatedBy := make([]int, n) // How many scores dominate i
+	dominating := make([][]int, n)
+	fronts := [][]int{{}}
+
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if dominates(scores[i], scores[j], objectives) {
+				dominating[i] = append(dominating[i], j)
+				dominatedBy[j]++
+			} else if dominates(scores[j], scores[i], objectives) {
+				dominating[j] = append(dominating[j], i)
+				dominatedBy[i]++
+			}
+		}
+	}
+	for i := 0; i < n; i++ {
+		if dominatedBy[i] == 0 {
+			fronts[0] = append(fronts[0], i)
+		}
+	}
+
+	for k := 0; len(fronts[k]) > 0; k++ {
+		next := []int{}
+		for _, i := range fronts[k] {
+			for _, j := range dominating[i] {
+				dominatedBy[j]--
+				if dominatedBy[j] == 0 {
+					next = append(next, j)
+				}
+			}
+		}
+		sort.Ints(next)
+		fronts = append(fronts, next)
+	}
+	return fronts[:len(fronts)-1]
+}
+
+// CrowdingDistance returns the NSGA-II crowding distance of each member of a front, in the
+// order of front. Boundary members get +Inf; larger values mean a less crowded region.
+func CrowdingDistance(scores [][]float64, front []int) []float64 {
+	distance := make([]float64, len(front))
+	if len(front) == 0 {
+		return distance
+	}
+	order := make([]int, len(front))
+	for m := range scores[front[0]] {
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return scores[front[order[a]]][m] < scores[front[order[b]]][m]
+		})
+		low, high := scores[front[order[0]]][m], scores[front[order[len(order)-1]]][m]
+		distance[order[0]] = math.Inf(1)
+		distance[order[len(order)-1]] = math.Inf(1)
+		if high == low || math.IsInf(high-low, 0) {
+			continue
+		}
+		for i := 1; i < len(order)-1; i++ {
+			gap := scores[front[order[i+1]]][m] - scores[front[order[i-1]]][m]
+			distance[order[i]] += gap / (high - low)
+		}
+	}
+	return distance
+}
+
+// ParetoFront returns the results that no other result dominates on the objectives.
+func ParetoFront(results []ModelResult, objectives []Objective) []ModelResult {
+	if len(results) == 0 {
+		return nil
+	}
+	fronts := NonDominatedSort(EvaluateObjectives(results, objectives), objectives)
+	front := make([]ModelResult, len(fronts[0]))
+	for i, idx := range fronts[0] {
+		front[i] = results[idx]
+	}
+	return front
+}
+
+// NSGA2Select picks n results by NSGA-II: whole fronts are taken in rank order, and the front
+// that does not fit is cut by descending crowding distance. n is clamped to [0, len(results)].
+func NSGA2Select(results []ModelResult, objectives []Objective, n int) []ModelResult {
+	if n < 0 {
+		n = 0
+	}
+	if n > len(results) {
+		n = len(results)
+	}
+	scores := EvaluateObjectives(results, objectives)
+	selected := make([]ModelResult, 0, n)
+	for _, front := range NonDominatedSort(scores, objectives) {
+		if len(selected) == n {
+			break
+		}
+		if len(selected)+len(front) <= n {
+			for _, idx := range front {
+				selected = append(selected, results[idx])
+			}
+			continue
+		}
+		distance := CrowdingDistance(scores, front)
+		order := make([]int, len(front))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool { return distance[order[a]] > distance[order[b]] })
+		for _, i := range order[:n-len(selected)] {
+			selected = append(selected, results[front[i]])
+		}
+	}
+	return selected
+}
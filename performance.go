@@ -4,7 +4,6 @@ import (
 	"encoding/csv"
 	"fmt"
 	"math"
-	"math/rand"
 	"os"
 	"sort"
 )
@@ -15,35 +14,25 @@ type ModelResult struct {
 	ClosenessBins []float64 // Closeness bins in [0, 100] per bin
 	ApproxScore   float64   // Approx score in [0, 100]
 	NeuronsAdded  int
+	Novelty       float64 // Behavioral novelty when grown with novelty search
 }
 
-// **computeTotalImprovement** calculates the weighted sum of improvements for a model.
+// **computeTotalImprovement** scores a model against the current metrics using the Phase's
+// ImprovementPolicy (by default the weighted sum 0.2 exact, 0.3 closeness, 0.5 approx).
 func (bp *Phase) ComputeTotalImprovement(result ModelResult, currentExactAcc, currentClosenessQuality, currentApproxScore float64) float64 {
-	newClosenessQuality := bp.ComputeClosenessQuality(result.ClosenessBins)
-	deltaExactAcc := result.ExactAcc - currentExactAcc
-	deltaApproxScore := result.ApproxScore - currentApproxScore
-	deltaClosenessQuality := newClosenessQuality - currentClosenessQuality
-	normDeltaExactAcc := deltaExactAcc / 100.0
-	normDeltaApproxScore := deltaApproxScore / 100.0
-	normDeltaClosenessQuality := deltaClosenessQuality / 100.0
-	weightExactAcc := 0.2
-	weightCloseness := 0.3
-	weightApproxScore := 0.5
-	return (weightExactAcc * normDeltaExactAcc) +
-		(weightCloseness * normDeltaClosenessQuality) +
-		(weightApproxScore * normDeltaApproxScore)
+	return bp.improvementPolicy().Improvement(result, currentExactAcc, currentClosenessQuality, currentApproxScore)
 }
 
 // printModelDetails displays detailed metrics for a model during tournament selection.
-func (bp *Phase) printModelDetails(result ModelResult, currentExactAcc, currentClosenessQuality, currentApproxScore float64) {
+func printModelDetails(policy ImprovementPolicy, result ModelResult, currentExactAcc, currentClosenessQuality, currentApproxScore float64) {
 	// Compute Closeness Quality from the model's bins
-	newClosenessQuality := bp.ComputeClosenessQuality(result.ClosenessBins)
+	newClosenessQuality := policy.ClosenessQuality(result.ClosenessBins)
 	// Calculate the differences (deltas) from current metrics
 	deltaExactAcc := result.ExactAcc - currentExactAcc
 	deltaClosenessQuality := newClosenessQuality - currentClosenessQuality
 	deltaApproxScore := result.ApproxScore - currentApproxScore
 	// Compute the total improvement score
-	improvement := bp.ComputeTotalImprovement(result, currentExactAcc, currentClosenessQuality, currentApproxScore)
+	improvement := policy.Improvement(result, currentExactAcc, currentClosenessQuality, currentApproxScore)
 	// Print all metrics with deltas and improvement
 	fmt.Printf("Model %d: ExactAcc=%.4f (Δ %.4f), ClosenessQuality=%.4f (Δ %.4f), ApproxScore=%.4f (Δ %.4f), Improvement=%.4f\n",
 		result.BP.ID, result.ExactAcc, deltaExactAcc, newClosenessQuality, deltaClosenessQuality, result.ApproxScore, deltaApproxScore, improvement)
@@ -51,30 +40,37 @@ func (bp *Phase) printModelDetails(result ModelResult, currentExactAcc, currentC
 
 // TournamentSelection selects the best model from a random subset and logs details if debug is enabled.
 func (bp *Phase) TournamentSelection(results []ModelResult, currentExactAcc, currentClosenessQuality, currentApproxScore float64, tournamentSize int) ModelResult {
+	return bp.TournamentSelectionWithPolicy(nil, results, currentExactAcc, currentClosenessQuality, currentApproxScore, tournamentSize)
+}
+
+// TournamentSelectionWithPolicy is TournamentSelection comparing the models with policy; nil
+// uses bp's ImprovementPolicy.
+func (bp *Phase) TournamentSelectionWithPolicy(policy ImprovementPolicy, results []ModelResult, currentExactAcc, currentClosenessQuality, currentApproxScore float64, tournamentSize int) ModelResult {
+	policy = bp.resolvePolicy(policy)
 	// Adjust tournament size if there are fewer results
 	if len(results) < tournamentSize {
 		tournamentSize = len(results)
 	}
 	// Randomly select indices for the tournament
-	perm := rand.Perm(len(results))
+	perm := bp.rng().Perm(len(results))
 	selectedIndices := perm[:tournamentSize]
 
 	// Start with the first model as the best
 	bestIdx := selectedIndices[0]
-	bestImprovement := bp.ComputeTotalImprovement(results[bestIdx], currentExactAcc, currentClosenessQuality, currentApproxScore)
+	bestImprovement := policy.Improvement(results[bestIdx], currentExactAcc, currentClosenessQuality, currentApproxScore)
 
 	// If debug is enabled, print the header and first model's details
 	if bp.Debug {
 		fmt.Println("Tournament Selection Details:")
-		bp.printModelDetails(results[bestIdx], currentExactAcc, currentClosenessQuality, currentApproxScore)
+		printModelDetails(policy, results[bestIdx], currentExactAcc, currentClosenessQuality, currentApproxScore)
 	}
 
 	// Evaluate the remaining models in the subset
 	for _, idx := range selectedIndices[1:] {
-		improvement := bp.ComputeTotalImprovement(results[idx], currentExactAcc, currentClosenessQuality, currentApproxScore)
+		improvement := policy.Improvement(results[idx], currentExactAcc, currentClosenessQuality, currentApproxScore)
 		// Log details if debug is on
 		if bp.Debug {
-			bp.printModelDetails(results[idx], currentExactAcc, currentClosenessQuality, currentApproxScore)
+			printModelDetails(policy, results[idx], currentExactAcc, currentClosenessQuality, currentApproxScore)
 		}
 		// Update the best model if this one has higher improvement
 		if improvement > bestImprovement {
@@ -92,21 +88,25 @@ func (bp *Phase) TournamentSelection(results []ModelResult, currentExactAcc, cur
 	return results[bestIdx]
 }
 
+// ComputeClosenessQuality condenses closeness bins into one value using the Phase's ImprovementPolicy.
 func (bp *Phase) ComputeClosenessQuality(bins []float64) float64 {
-	quality := 0.0
-	weights := []float64{1.0, 0.9, 0.8, 0.7, 0.6, 0.5, 0.4, 0.3, 0.2, 0.1} // Higher weight for lower bins
-	for i := 0; i < len(bins)-1; i++ {                                     // Exclude >0.9 bin if desired
-		quality += bins[i] * weights[i]
-	}
-	return quality
+	return bp.improvementPolicy().ClosenessQuality(bins)
 }
 
+// SelectBestModel returns the result that improves most on the current metrics, and its improvement.
 func (bp *Phase) SelectBestModel(results []ModelResult, currentExactAcc, currentClosenessQuality, currentApproxScore float64) (ModelResult, float64) {
+	return bp.SelectBestModelWithPolicy(nil, results, currentExactAcc, currentClosenessQuality, currentApproxScore)
+}
+
+// SelectBestModelWithPolicy is SelectBestModel comparing the models with policy; nil uses bp's
+// ImprovementPolicy.
+func (bp *Phase) SelectBestModelWithPolicy(policy ImprovementPolicy, results []ModelResult, currentExactAcc, currentClosenessQuality, currentApproxScore float64) (ModelResult, float64) {
+	policy = bp.resolvePolicy(policy)
 	var bestModel ModelResult
 	bestImprovement := -math.MaxFloat64 // Start with the lowest possible value
 
 	for _, model := range results {
-		improvement := bp.ComputeTotalImprovement(model, currentExactAcc, currentClosenessQuality, currentApproxScore)
+		improvement := policy.Improvement(model, currentExactAcc, currentClosenessQuality, currentApproxScore)
 		if improvement > bestImprovement {
 			bestImprovement = improvement
 			bestModel = model
@@ -0,0 +1,381 @@
+package phase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// FitnessFunc scores a Phase; higher is better.
+// With more than one worker it is called concurrently, each call on a different Phase.
+type FitnessFunc func(bp *Phase) float64
+
+// MutationRates holds the probability of applying each mutation operator to a new offspring.
+type MutationRates struct {
+	AddNeuron        float64 // AddNeuronFromPreOutputs
+	AddConnection    float64 // AddConnection
+	RemoveConnection float64 // RemoveConnection
+	RemoveNeuron     float64 // RemoveRandomNeuron
+	AdjustWeights    float64 // AdjustWeights
+	AdjustBiases     float64 // AdjustBiases
+	ChangeActivation float64 // ChangeActivationFunction
+	ChangeNeuronType float64 // ChangeSingleNeuronType
+}
+
+// DefaultMutationRates favors weight and bias changes over structural ones.
+func DefaultMutationRates() MutationRates {
+	return MutationRates{
+		AddNeuron:        0.05,
+		AddConnection:    0.1,
+		RemoveConnection: 0.05,
+		RemoveNeuron:     0.02,
+		AdjustWeights:    0.8,
+		AdjustBiases:     0.5,
+		ChangeActivation: 0.05,
+		ChangeNeuronType: 0.02,
+	}
+}
+
+// SelectionStrategy picks a parent from a group of evaluated individuals.
+type SelectionStrategy interface {
+	Select(candidates []*Individual, rng *rand.Rand) *Individual
+}
+
+// TournamentSelector returns the fittest of Size randomly drawn candidates.
+type TournamentSelector struct {
+	Size int
+}
+
+// Select runs one tournament.
+func (s TournamentSelector) Select(candidates []*Individual, rng *rand.Rand) *Individual {
+	size := s.Size
+	if size < 1 {
+		size = 1
+	}
+	if size > len(candidates) {
+		size = len(candidates)
+	}
+	var best *Individual
+	for _, idx := range rng.Perm(len(candidates))[:size] {
+		if best == nil || candidates[idx].Fitness > best.Fitness {
+			best = candidates[idx]
+		}
+	}
+	return best
+}
+
+// TruncationSelector picks uniformly among the top Fraction of the candidates.
+type TruncationSelector struct {
+	Fraction float64 // Share of candidates eligible for selection, in (0, 1]
+}
+
+// Select returns a random individual from the top of the candidates.
+func (s TruncationSelector) Select(candidates []*Individual, rng *rand.Rand) *Individual {
+	sorted := append([]*Individual{}, candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Fitness > sorted[j].Fitness })
+	n := int(math.Ceil(float64(len(sorted)) * s.Fraction))
+	if n < 1 {
+		n = 1
+	}
+	if n > len(sorted) {
+		n = len(sorted)
+	}
+	return sorted[rng.Intn(n)]
+}
+
+// PopulationConfig configures a Population.
+type PopulationConfig struct {
+	Size             int               // Number of individuals per generation
+	Fitness          FitnessFunc       // Required
+	Mutation         MutationRates     // Per-offspring operator probabilities
+	MutationConfig   *MutationConfig   // Overrides Mutation; its 1/5th rule learns from offspring that beat their parent
+	CrossoverRate    float64           // Probability that an offspring is bred from two parents
+	Elitism          int               // Best individuals copied unchanged into the next generation
+	Selection        SelectionStrategy // Defaults to TournamentSelector{Size: 3}
+	SpeciesThreshold float64           // PhaseSimilarity percentage for ClusterPhasesBySpecies; 0 disables speciation
+	Speciation       *SpeciationConfig // Persistent NEAT species with fitness sharing; overrides SpeciesThreshold
+	Workers          int               // Concurrent fitness evaluations; defaults to runtime.NumCPU()
+	Seed             int64             // Seeds selection and breeding decisions
+}
+
+// Individual is a member of a Population.
+type Individual struct {
+	BP              *Phase
+	Fitness         float64
+	AdjustedFitness float64 // Fitness after sharing within the species (Speciation only)
+	SpeciesID       int     // Species of the individual in the last speciation, or 0 without speciation
+	Evaluated       bool    // Whether Fitness is current
+	ParentFitness   float64 // Fitness of the first parent, for offspring bred by the population
+	bred            bool
+}
+
+// GenerationStats summarizes one evaluated generation.
+type GenerationStats struct {
+	Generation   int
+	BestFitness  float64
+	MeanFitness  float64
+	WorstFitness float64
+	Species      int
+	Extinct      int // Species removed for stagnation in this generation
+	Best         *Individual
+}
+
+// Population runs generational evolution: evaluate, speciate, select, breed and mutate.
+type Population struct {
+	Config      PopulationConfig
+	Individuals []*Individual
+	Generation  int         // Number of completed generations
+	Best        *Individual // Fittest individual seen so far
+
+	Speciator *Speciator // Non-nil when Config.Speciation is set
+
+	species map[int][]*Individual
+	extinct int
+	hooks   []func(GenerationStats)
+	rng     *rand.Rand
+}
+
+// NewPopulation creates a population of cfg.Size copies of seed.
+// The first individual is an exact copy; the others are mutated once. Every individual, and
+// every offspring bred later, gets its own Rand seeded from cfg.Seed, so a run with the same
+// seed and a deterministic fitness function repeats exactly, however many workers it uses.
+func NewPopulation(seed *Phase, cfg PopulationConfig) (*Population, error) {
+	if seed == nil {
+		return nil, fmt.Errorf("population: seed phase is nil")
+	}
+	if cfg.Size < 1 {
+		return nil, fmt.Errorf("population: size must be at least 1, got %d", cfg.Size)
+	}
+	if cfg.Fitness == nil {
+		return nil, fmt.Errorf("population: fitness function is required")
+	}
+	if cfg.Elitism < 0 || cfg.Elitism > cfg.Size {
+		return nil, fmt.Errorf("population: elitism %d out of range [0, %d]", cfg.Elitism, cfg.Size)
+	}
+	if cfg.Selection == nil {
+		cfg.Selection = TournamentSelector{Size: 3}
+	}
+	if cfg.Workers < 1 {
+		cfg.Workers = runtime.NumCPU()
+	}
+
+	p := &Population{
+		Config: cfg,
+		rng:    rand.New(rand.NewSource(cfg.Seed)),
+	}
+	if cfg.Speciation != nil {
+		p.Speciator = NewSpeciator(*cfg.Speciation)
+	}
+	for i := 0; i < cfg.Size; i++ {
+		bp := seed.Copy()
+		bp.seedRand(p.rng.Int63())
+		if i > 0 {
+			p.mutate(bp)
+		}
+		p.Individuals = append(p.Individuals, &Individual{BP: bp})
+	}
+	return p, nil
+}
+
+// OnGeneration registers a hook called with the statistics of every evaluated generation.
+func (p *Population) OnGeneration(hook func(GenerationStats)) {
+	p.hooks = append(p.hooks, hook)
+}
+
+// Evaluate scores every individual whose fitness is not current.
+func (p *Population) Evaluate() {
+	pending := make(chan *Individual, len(p.Individuals))
+	for _, ind := range p.Individuals {
+		if !ind.Evaluated {
+			pending <- ind
+		}
+	}
+	close(pending)
+
+	var wg sync.WaitGroup
+	for w := 0; w < p.Config.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ind := range pending {
+				ind.Fitness = p.Config.Fitness(ind.BP)
+				if math.IsNaN(ind.Fitness) {
+					ind.Fitness = math.Inf(-1)
+				}
+				ind.Evaluated = true
+			}
+		}()
+	}
+	wg.Wait()
+
+	if cfg := p.Config.MutationConfig; cfg != nil {
+		for _, ind := range p.Individuals {
+			if ind.bred {
+				cfg.ReportOutcome(ind.Fitness > ind.ParentFitness)
+				ind.bred = false
+			}
+		}
+	}
+}
+
+// Step evaluates and speciates the current generation, runs the hooks, and replaces it with
+// the next generation bred from it. It returns the statistics of the evaluated generation.
+func (p *Population) Step() GenerationStats {
+	p.Evaluate()
+	p.speciate()
+
+	sort.SliceStable(p.Individuals, func(i, j int) bool {
+		return p.Individuals[i].Fitness > p.Individuals[j].Fitness
+	})
+	if p.Best == nil || p.Individuals[0].Fitness > p.Best.Fitness {
+		p.Best = p.Individuals[0]
+	}
+	stats := p.stats()
+	for _, hook := range p.hooks {
+		hook(stats)
+	}
+
+	p.Individuals = p.breed()
+	p.Generation++
+	return stats
+}
+
+// Run calls Step for the given number of generations, stopping early when ctx is done.
+// It returns the statistics of the last completed generation.
+func (p *Population) Run(ctx context.Context, generations int) (GenerationStats, error) {
+	var stats GenerationStats
+	for g := 0; g < generations; g++ {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		stats = p.Step()
+	}
+	return stats, nil
+}
+
+// speciate groups the individuals into species. With a Speciator it also shares fitness and
+// removes stagnant species; otherwise it uses ClusterPhasesBySpecies, and without a threshold
+// the whole population is one species.
+func (p *Population) speciate() {
+	p.species = make(map[int][]*Individual)
+	p.extinct = 0
+	if p.Speciator != nil {
+		p.Speciator.Speciate(p.Individuals, p.rng)
+		p.Speciator.ShareFitness()
+		p.extinct = len(p.Speciator.RemoveStagnant())
+		for _, sp := range p.Speciator.Species {
+			p.species[sp.ID] = sp.Members
+		}
+		return
+	}
+	if p.Config.SpeciesThreshold <= 0 {
+		for _, ind := range p.Individuals {
+			ind.SpeciesID = 0
+		}
+		p.species[0] = p.Individuals
+		return
+	}
+
+	phases := make(map[int]*Phase, len(p.Individuals))
+	for i, ind := range p.Individuals {
+		phases[i] = ind.BP
+	}
+	clusters := ClusterPhasesBySpecies(phases, p.Config.SpeciesThreshold)
+
+	// Order species by their lowest member index so the numbering is stable.
+	groups := make([][]int, 0, len(clusters))
+	for _, members := range clusters {
+		sort.Ints(members)
+		groups = append(groups, members)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+
+	for s, members := range groups {
+		for _, idx := range members {
+			p.Individuals[idx].SpeciesID = s
+			p.species[s] = append(p.species[s], p.Individuals[idx])
+		}
+	}
+}
+
+// stats summarizes the sorted, evaluated population.
+func (p *Population) stats() GenerationStats {
+	sum := 0.0
+	for _, ind := range p.Individuals {
+		sum += ind.Fitness
+	}
+	return GenerationStats{
+		Generation:   p.Generation,
+		BestFitness:  p.Individuals[0].Fitness,
+		MeanFitness:  sum / float64(len(p.Individuals)),
+		WorstFitness: p.Individuals[len(p.Individuals)-1].Fitness,
+		Species:      len(p.species),
+		Extinct:      p.extinct,
+		Best:         p.Individuals[0],
+	}
+}
+
+// breed builds the next generation from the sorted, speciated population.
+// Elites are carried over unchanged; every other offspring is a mutated copy of one parent
+// or a crossover of two parents from the same species. With a Speciator each surviving species
+// breeds the number of offspring allocated to it from its shared fitness.
+func (p *Population) breed() []*Individual {
+	next := make([]*Individual, 0, p.Config.Size)
+	for i := 0; i < p.Config.Elitism && i < len(p.Individuals); i++ {
+		elite := *p.Individuals[i]
+		next = append(next, &elite)
+	}
+
+	if p.Speciator != nil {
+		p.Speciator.AllocateOffspring(p.Config.Size - len(next))
+		for _, sp := range p.Speciator.Species {
+			for i := 0; i < sp.Offspring; i++ {
+				first := p.Config.Selection.Select(sp.Members, p.rng)
+				next = append(next, p.offspring(first, sp.Members))
+			}
+		}
+	}
+
+	for len(next) < p.Config.Size {
+		first := p.Config.Selection.Select(p.Individuals, p.rng)
+		next = append(next, p.offspring(first, p.species[first.SpeciesID]))
+	}
+	return next
+}
+
+// offspring breeds a mutated child of first, crossed with a mate from mates with
+// probability CrossoverRate. Too few mates fall back to the whole population.
+func (p *Population) offspring(first *Individual, mates []*Individual) *Individual {
+	var child *Phase
+	if p.rng.Float64() < p.Config.CrossoverRate {
+		if len(mates) < 2 {
+			mates = p.Individuals
+		}
+		second := p.Config.Selection.Select(mates, p.rng)
+		child = crossover(first.BP, second.BP, first.Fitness, second.Fitness, p.rng)
+	} else {
+		child = first.BP.Copy()
+	}
+	child.seedRand(p.rng.Int63())
+	p.mutate(child)
+	return &Individual{BP: child, ParentFitness: first.Fitness, bred: true}
+}
+
+// mutate applies the configured operators to bp.
+func (p *Population) mutate(bp *Phase) {
+	if p.Config.MutationConfig != nil {
+		bp.ApplyMutations(p.Config.MutationConfig, p.rng)
+		return
+	}
+	applyMutationRates(bp, p.Config.Mutation, p.rng)
+}
+
+// applyMutationRates applies each operator in mutations.go to bp with its probability in rates.
+func applyMutationRates(bp *Phase, rates MutationRates, rng *rand.Rand) {
+	cfg := rates.MutationConfig()
+	bp.ApplyMutations(&cfg, rng)
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"math"
 	"math/cmplx"
-	"math/rand"
 )
 
 // QuantumState represents a quantum state with amplitude and Phase
@@ -200,7 +199,7 @@ func (bp *Phase) measureQuantumState(superposition []complex128) float64 {
 
 	fmt.Printf("Measuring quantum state with probabilities: %v\n", probabilities)
 
-	rnd := rand.Float64()
+	rnd := bp.rng().Float64()
 	cumulative := 0.0
 	for i, prob := range probabilities {
 		cumulative += prob
@@ -213,7 +212,7 @@ func (bp *Phase) measureQuantumState(superposition []complex128) float64 {
 
 // measureEntangledQubits simulates the measurement of entangled qubits with correlated outcomes.
 func (bp *Phase) measureEntangledQubits(q1, q2 *QuantumNeuron) {
-	rnd := rand.Float64()
+	rnd := bp.rng().Float64()
 	if rnd < 0.5 {
 		// Both qubits collapse to |0⟩
 		q1.Superposition = []complex128{1, 0}
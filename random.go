@@ -0,0 +1,148 @@
+package phase
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// globalSource draws from the package-level math/rand functions, which are safe for
+// concurrent use.
+type globalSource struct{}
+
+func (globalSource) Int63() int64    { return rand.Int63() }
+func (globalSource) Uint64() uint64  { return rand.Uint64() }
+func (globalSource) Seed(seed int64) {}
+
+// globalRand lets code written against a *rand.Rand fall back to the global math/rand.
+var globalRand = rand.New(globalSource{})
+
+// CountingSource is a seeded math/rand source that counts how many values it has produced.
+// Its state is just (seed, draws), so it can be saved with State and rebuilt exactly with
+// RestoreCountingSource. It is not safe for concurrent use.
+type CountingSource struct {
+	seed  int64
+	draws uint64
+	src   rand.Source64
+}
+
+// NewCountingSource returns a source seeded with seed.
+func NewCountingSource(seed int64) *CountingSource {
+	return &CountingSource{seed: seed, src: rand.NewSource(seed).(rand.Source64)}
+}
+
+// RestoreCountingSource returns a source in the state reported by State: seeded with seed and
+// advanced by draws values.
+func RestoreCountingSource(seed int64, draws uint64) *CountingSource {
+	s := NewCountingSource(seed)
+	for s.draws < draws {
+		s.Uint64()
+	}
+	return s
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer.
+func (s *CountingSource) Int63() int64 {
+	s.draws++
+	return s.src.Int63()
+}
+
+// Uint64 returns a pseudo-random 64-bit integer.
+func (s *CountingSource) Uint64() uint64 {
+	s.draws++
+	return s.src.Uint64()
+}
+
+// Seed reseeds the source and resets its draw count.
+func (s *CountingSource) Seed(seed int64) {
+	s.seed = seed
+	s.draws = 0
+	s.src.Seed(seed)
+}
+
+// State returns the seed and the number of values drawn since seeding.
+func (s *CountingSource) State() (seed int64, draws uint64) {
+	return s.seed, s.draws
+}
+
+// NewPhaseWithSeed returns an empty Phase whose randomness comes from a Rand seeded with seed.
+// Build layers into it with InitializeWithLayers to get seeded initial weights.
+func NewPhaseWithSeed(seed int64) *Phase {
+	bp := NewPhase()
+	bp.seedRand(seed)
+	return bp
+}
+
+// rng returns the Phase's Rand, or the global math/rand when it has none.
+func (bp *Phase) rng() *rand.Rand {
+	if bp.Rand == nil {
+		return globalRand
+	}
+	return bp.Rand
+}
+
+// childRand returns a new Rand seeded from rng.
+func childRand(rng *rand.Rand) *rand.Rand {
+	return rand.New(rand.NewSource(rng.Int63()))
+}
+
+// seedRand gives the Phase a new Rand seeded with seed and records the seed for Copy.
+func (bp *Phase) seedRand(seed int64) {
+	bp.Rand = rand.New(rand.NewSource(seed))
+	bp.randSeed, bp.seededRand, bp.copies = seed, bp.Rand, 0
+}
+
+// copySeed returns the seed and ID of the next copy of bp. They depend only on the seed of
+// bp.Rand and how many copies were made before, so copying leaves bp.Rand alone. A Rand that
+// was assigned directly has no recorded seed; the first copy draws one from it. bp.Rand must
+// not be nil, and callers hold copyRandMu.
+func (bp *Phase) copySeed() (seed int64, id int) {
+	bp.recordSeed()
+	seed = int64(splitMix64(uint64(bp.randSeed) + uint64(bp.copies+1)*0x9e3779b97f4a7c15))
+	bp.copies++
+	return seed, int(splitMix64(uint64(seed))%10000) + 1
+}
+
+// recordSeed gives a Rand that was assigned directly a seed for its copies, drawn from it.
+func (bp *Phase) recordSeed() {
+	if bp.seededRand != bp.Rand {
+		bp.randSeed, bp.seededRand, bp.copies = bp.Rand.Int63(), bp.Rand, 0
+	}
+}
+
+// randState is what copySeed depends on, so GrowSession can save and restore it.
+type randState struct {
+	Seed   int64 `json:"seed"`
+	Copies int64 `json:"copies"`
+}
+
+// saveRandState returns the state of bp's copy seeding, or nil when bp has no Rand.
+func (bp *Phase) saveRandState() *randState {
+	if bp.Rand == nil {
+		return nil
+	}
+	copyRandMu.Lock()
+	defer copyRandMu.Unlock()
+	bp.recordSeed()
+	return &randState{Seed: bp.randSeed, Copies: bp.copies}
+}
+
+// restoreRandState reseeds bp from a state saved by saveRandState; nil leaves bp unseeded.
+func (bp *Phase) restoreRandState(state *randState) {
+	if state == nil {
+		bp.Rand, bp.seededRand = nil, nil
+		return
+	}
+	bp.seedRand(state.Seed)
+	bp.copies = state.Copies
+}
+
+// splitMix64 is the SplitMix64 finalizer, which turns nearby inputs into unrelated outputs.
+func splitMix64(z uint64) uint64 {
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
+
+// copyRandMu serializes Copy's updates to the original's copy count, since several goroutines
+// may copy the same Phase at once.
+var copyRandMu sync.Mutex
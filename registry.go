@@ -0,0 +1,465 @@
+package phase
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RegistryEntry describes one model stored in a Registry.
+type RegistryEntry struct {
+	Hash     string             `json:"hash"` // SHA-256 of the network, excluding ID and Metadata
+	Task     string             `json:"task,omitempty"`
+	Tags     []string           `json:"tags,omitempty"`
+	Metrics  map[string]float64 `json:"metrics,omitempty"`
+	Metadata *ModelMetadata     `json:"metadata,omitempty"`
+	Lineage  []LineageEvent     `json:"lineage,omitempty"` // Ancestry of the model when it had a Genealogy
+	Created  time.Time          `json:"created"`
+	Updated  time.Time          `json:"updated"`
+}
+
+// HasTag reports whether the entry carries tag.
+func (e RegistryEntry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// PutOptions describes a model being added to a Registry.
+type PutOptions struct {
+	Task    string
+	Tags    []string
+	Metrics map[string]float64
+}
+
+// RegistryQuery filters and orders Registry.Search results.
+type RegistryQuery struct {
+	Task      string   // Only entries of this task; empty matches every task
+	Tags      []string // Entries must carry all of these tags
+	Metric    string   // Metric used by MinMetric, MaxMetric and sorting; entries without it are skipped
+	MinMetric *float64 // Inclusive lower bound on Metric
+	MaxMetric *float64 // Inclusive upper bound on Metric
+	Ascending bool     // Sort by Metric ascending instead of descending
+	Limit     int      // Maximum number of results; 0 returns all
+}
+
+// GCOptions controls Registry.GC.
+type GCOptions struct {
+	KeepTop    int    // Entries in each task's top KeepTop by Metric survive; 0 keeps every entry
+	Metric     string // Metric ranking the hall of fame
+	Ascending  bool   // Lower Metric is better
+	KeepTagged bool   // Tagged entries survive regardless of rank
+}
+
+// Registry is a model store in a local directory. Networks are stored content-addressed in
+// objects/<hash>.json, and each entry's task, tags, metrics, metadata and lineage in
+// entries/<hash>.json, so the registry needs nothing but the file system. Writes go through
+// a temporary file and a rename, so a crash never leaves a half-written file behind.
+type Registry struct {
+	Dir string
+
+	mu      sync.Mutex
+	entries map[string]*RegistryEntry
+}
+
+// OpenRegistry opens the registry in dir, creating the directory layout if needed.
+func OpenRegistry(dir string) (*Registry, error) {
+	for _, sub := range []string{"objects", "entries"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create registry directory: %v", err)
+		}
+	}
+	r := &Registry{Dir: dir, entries: make(map[string]*RegistryEntry)}
+
+	files, err := os.ReadDir(filepath.Join(dir, "entries"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry entries: %v", err)
+	}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "entries", file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read registry entry %s: %v", file.Name(), err)
+		}
+		var entry RegistryEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse registry entry %s: %v", file.Name(), err)
+		}
+		if entry.Metrics == nil {
+			entry.Metrics = make(map[string]float64)
+		}
+		r.entries[entry.Hash] = &entry
+	}
+	return r, nil
+}
+
+// ContentHash returns the SHA-256 of the network's JSON form without its ID, Metadata and the
+// neurons' runtime state (values, LSTM cell states and IsNew flags), so copies of the same
+// network hash the same, before and after a Forward. The JSON it returns leaves them out too.
+func (bp *Phase) ContentHash() (string, []byte, error) {
+	content := *bp
+	content.ID = 0
+	content.Metadata = nil
+	content.Neurons = make(map[int]*Neuron, len(bp.Neurons))
+	for id, neuron := range bp.Neurons {
+		stripped := *neuron
+		stripped.Value, stripped.CellState, stripped.IsNew = 0, 0, false
+		content.Neurons[id] = &stripped
+	}
+	data, err := json.Marshal(&content)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to serialize Phase: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), data, nil
+}
+
+// Put stores bp and returns its entry. Storing a network that is already present merges the
+// tags and metrics into the existing entry; a non-empty Task replaces the old one.
+func (r *Registry) Put(bp *Phase, opts PutOptions) (RegistryEntry, error) {
+	hash, data, err := bp.ContentHash()
+	if err != nil {
+		return RegistryEntry{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	objectPath := r.objectPath(hash)
+	if _, err := os.Stat(objectPath); os.IsNotExist(err) {
+		if err := writeFileAtomic(objectPath, data); err != nil {
+			return RegistryEntry{}, err
+		}
+	}
+
+	now := time.Now()
+	entry, exists := r.entries[hash]
+	if !exists {
+		entry = &RegistryEntry{Hash: hash, Metrics: make(map[string]float64), Created: now}
+	}
+	entry.Updated = now
+	if opts.Task != "" {
+		entry.Task = opts.Task
+	}
+	for _, tag := range opts.Tags {
+		if !entry.HasTag(tag) {
+			entry.Tags = append(entry.Tags, tag)
+		}
+	}
+	for name, value := range opts.Metrics {
+		entry.Metrics[name] = replaceNaN(value)
+	}
+	if bp.Metadata != nil {
+		metadata := *bp.Metadata
+		entry.Metadata = &metadata
+		if bp.Genealogy != nil && metadata.ModelID != "" {
+			entry.Lineage = bp.Genealogy.Ancestry(metadata.ModelID)
+		}
+	}
+
+	if err := r.saveEntry(entry); err != nil {
+		return RegistryEntry{}, err
+	}
+	r.entries[hash] = entry
+	return *entry, nil
+}
+
+// PutResult stores the model of a Grow or tournament result with its exact_acc, closeness and
+// approx_score metrics.
+func (r *Registry) PutResult(result ModelResult, task string, tags ...string) (RegistryEntry, error) {
+	return r.Put(result.BP, PutOptions{
+		Task: task,
+		Tags: tags,
+		Metrics: map[string]float64{
+			"exact_acc":    result.ExactAcc,
+			"closeness":    result.BP.ComputeClosenessQuality(result.ClosenessBins),
+			"approx_score": result.ApproxScore,
+		},
+	})
+}
+
+// Get loads the model stored under hash. Its Metadata is restored from the entry.
+func (r *Registry) Get(hash string) (*Phase, error) {
+	r.mu.Lock()
+	entry, exists := r.entries[hash]
+	r.mu.Unlock()
+	if !exists {
+		return nil, fmt.Errorf("registry: no model with hash %s", hash)
+	}
+
+	data, err := os.ReadFile(r.objectPath(hash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model %s: %v", hash, err)
+	}
+	bp := NewPhase()
+	if err := bp.DeserializesFromJSON(string(data)); err != nil {
+		return nil, fmt.Errorf("failed to load model %s: %v", hash, err)
+	}
+	if entry.Metadata != nil {
+		metadata := *entry.Metadata
+		bp.Metadata = &metadata
+	}
+	return bp, nil
+}
+
+// Entry returns the entry stored under hash.
+func (r *Registry) Entry(hash string) (RegistryEntry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, exists := r.entries[hash]
+	if !exists {
+		return RegistryEntry{}, false
+	}
+	return *entry, true
+}
+
+// Tag adds tags to an entry.
+func (r *Registry) Tag(hash string, tags ...string) error {
+	return r.update(hash, func(entry *RegistryEntry) {
+		for _, tag := range tags {
+			if !entry.HasTag(tag) {
+				entry.Tags = append(entry.Tags, tag)
+			}
+		}
+	})
+}
+
+// Untag removes tags from an entry.
+func (r *Registry) Untag(hash string, tags ...string) error {
+	return r.update(hash, func(entry *RegistryEntry) {
+		kept := entry.Tags[:0]
+		for _, t := range entry.Tags {
+			if !inStrings(t, tags) {
+				kept = append(kept, t)
+			}
+		}
+		entry.Tags = kept
+	})
+}
+
+// SetMetrics adds or replaces metrics of an entry.
+func (r *Registry) SetMetrics(hash string, metrics map[string]float64) error {
+	return r.update(hash, func(entry *RegistryEntry) {
+		if entry.Metrics == nil {
+			entry.Metrics = make(map[string]float64)
+		}
+		for name, value := range metrics {
+			entry.Metrics[name] = replaceNaN(value)
+		}
+	})
+}
+
+// Search returns the entries matching q. With a Metric they are sorted by it (best first
+// unless Ascending); otherwise they are ordered by creation time.
+func (r *Registry) Search(q RegistryQuery) []RegistryEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := []RegistryEntry{}
+	for _, entry := range r.entries {
+		if q.Task != "" && entry.Task != q.Task {
+			continue
+		}
+		tagged := true
+		for _, tag := range q.Tags {
+			if !entry.HasTag(tag) {
+				tagged = false
+				break
+			}
+		}
+		if !tagged {
+			continue
+		}
+		if q.Metric != "" {
+			value, ok := entry.Metrics[q.Metric]
+			if !ok || math.IsNaN(value) {
+				continue
+			}
+			if (q.MinMetric != nil && value < *q.MinMetric) || (q.MaxMetric != nil && value > *q.MaxMetric) {
+				continue
+			}
+		}
+		results = append(results, *entry)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if q.Metric != "" {
+			a, b := results[i].Metrics[q.Metric], results[j].Metrics[q.Metric]
+			if a != b {
+				if q.Ascending {
+					return a < b
+				}
+				return a > b
+			}
+		}
+		if !results[i].Created.Equal(results[j].Created) {
+			return results[i].Created.Before(results[j].Created)
+		}
+		return results[i].Hash < results[j].Hash
+	})
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results
+}
+
+// HallOfFame returns the n best entries of a task by metric, best first.
+func (r *Registry) HallOfFame(task, metric string, n int) []RegistryEntry {
+	return r.Search(RegistryQuery{Task: task, Metric: metric, Limit: n})
+}
+
+// Delete removes an entry and its network.
+func (r *Registry) Delete(hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.entries[hash]; !exists {
+		return fmt.Errorf("registry: no model with hash %s", hash)
+	}
+	return r.remove(hash)
+}
+
+// GC removes every entry that falls outside its task's hall of fame, then deletes network
+// files no entry refers to. It returns the hashes of the removed entries.
+func (r *Registry) GC(opts GCOptions) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := []string{}
+	if opts.KeepTop > 0 {
+		byTask := make(map[string][]*RegistryEntry)
+		for _, entry := range r.entries {
+			byTask[entry.Task] = append(byTask[entry.Task], entry)
+		}
+		for _, entries := range byTask {
+			sort.SliceStable(entries, func(i, j int) bool {
+				a, okA := entries[i].Metrics[opts.Metric]
+				b, okB := entries[j].Metrics[opts.Metric]
+				if okA != okB {
+					return okA // Entries without the metric rank last
+				}
+				if a != b {
+					if opts.Ascending {
+						return a < b
+					}
+					return a > b
+				}
+				return entries[i].Hash < entries[j].Hash
+			})
+			for rank, entry := range entries {
+				if rank < opts.KeepTop || (opts.KeepTagged && len(entry.Tags) > 0) {
+					continue
+				}
+				if err := r.remove(entry.Hash); err != nil {
+					return removed, err
+				}
+				removed = append(removed, entry.Hash)
+			}
+		}
+	}
+
+	objects, err := os.ReadDir(filepath.Join(r.Dir, "objects"))
+	if err != nil {
+		return removed, fmt.Errorf("failed to read registry objects: %v", err)
+	}
+	for _, object := range objects {
+		hash := strings.TrimSuffix(object.Name(), ".json")
+		if _, referenced := r.entries[hash]; !referenced {
+			if err := os.Remove(filepath.Join(r.Dir, "objects", object.Name())); err != nil {
+				return removed, fmt.Errorf("failed to remove unreferenced model %s: %v", hash, err)
+			}
+		}
+	}
+	sort.Strings(removed)
+	return removed, nil
+}
+
+// update applies change to an entry and saves it.
+func (r *Registry) update(hash string, change func(entry *RegistryEntry)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, exists := r.entries[hash]
+	if !exists {
+		return fmt.Errorf("registry: no model with hash %s", hash)
+	}
+	change(entry)
+	entry.Updated = time.Now()
+	return r.saveEntry(entry)
+}
+
+// remove deletes an entry and its network file. The caller holds r.mu.
+func (r *Registry) remove(hash string) error {
+	if err := os.Remove(r.entryPath(hash)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove registry entry %s: %v", hash, err)
+	}
+	if err := os.Remove(r.objectPath(hash)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove model %s: %v", hash, err)
+	}
+	delete(r.entries, hash)
+	return nil
+}
+
+func (r *Registry) saveEntry(entry *RegistryEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize registry entry %s: %v", entry.Hash, err)
+	}
+	return writeFileAtomic(r.entryPath(entry.Hash), data)
+}
+
+func (r *Registry) objectPath(hash string) string {
+	return filepath.Join(r.Dir, "objects", hash+".json")
+}
+
+func (r *Registry) entryPath(hash string) string {
+	return filepath.Join(r.Dir, "entries", hash+".json")
+}
+
+// inStrings reports whether val is in arr.
+func inStrings(val string, arr []string) bool {
+	for _, v := range arr {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
+
+// writeFileAtomic writes data to a temporary file next to fileName and renames it into place.
+func writeFileAtomic(fileName string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(fileName), filepath.Base(fileName)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for '%s': %v", fileName, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write '%s': %v", fileName, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to sync '%s': %v", fileName, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to close '%s': %v", fileName, err)
+	}
+	if err := os.Rename(tmp.Name(), fileName); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to move '%s' into place: %v", fileName, err)
+	}
+	return nil
+}
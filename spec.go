@@ -0,0 +1,522 @@
+package phase
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ArchitectureSpec is a declarative description of a network built from named neuron groups.
+//
+// Specs are written in YAML (JSON is accepted as well):
+//
+//	name: classifier
+//	seed: 7
+//	groups:
+//	  - {name: pixels, size: 16, type: input}
+//	  - {name: features, size: 8, type: dense, activation: relu}
+//	  - {name: out, size: 3, activation: linear, output: true}
+//	connections:
+//	  - {from: pixels, to: features, pattern: conv, kernel: 9, stride: 1, init: he_normal}
+//	  - {from: features, to: out, pattern: sparse, density: 0.5, init: xavier_uniform}
+//
+// Neuron IDs are assigned contiguously in group declaration order starting at 0, and all
+// random initialization is driven by Seed, so compiling the same spec always yields the same Phase.
+type ArchitectureSpec struct {
+	Name        string           `yaml:"name"`
+	Seed        int64            `yaml:"seed"`
+	Groups      []GroupSpec      `yaml:"groups"`
+	Connections []ConnectionSpec `yaml:"connections"`
+
+	groupIDs map[string][]int
+}
+
+// GroupSpec describes a group of neurons that share a type and activation.
+type GroupSpec struct {
+	Name        string  `yaml:"name"`
+	Size        int     `yaml:"size"`
+	Type        string  `yaml:"type"`       // input, dense, rnn, lstm, cnn, batch_norm, dropout, attention, nca
+	Activation  string  `yaml:"activation"` // Defaults to linear
+	Output      bool    `yaml:"output"`
+	BiasInit    string  `yaml:"bias_init"`  // Initializer for biases, defaults to zeros
+	BiasValue   float64 `yaml:"bias_value"` // Parameter for the bias initializer
+	DropoutRate float64 `yaml:"dropout_rate"`
+	Kernels     int     `yaml:"kernels"`     // Number of kernels for cnn groups
+	KernelSize  int     `yaml:"kernel_size"` // Size of each kernel for cnn groups
+
+	lines map[string]int
+}
+
+// ConnectionSpec describes how two groups are wired together.
+type ConnectionSpec struct {
+	From      string  `yaml:"from"`
+	To        string  `yaml:"to"`
+	Pattern   string  `yaml:"pattern"` // full (default), sparse, one_to_one, conv
+	Density   float64 `yaml:"density"` // Fraction of pairs kept for sparse connections
+	Kernel    int     `yaml:"kernel"`  // Receptive field size for conv connections
+	Stride    int     `yaml:"stride"`  // Step between receptive fields for conv connections
+	Init      string  `yaml:"init"`    // Weight initializer, defaults to phase_default
+	InitValue float64 `yaml:"init_value"`
+
+	lines map[string]int
+}
+
+// SpecError reports a problem in an architecture spec together with its location.
+type SpecError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *SpecError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("spec: %s", e.Msg)
+	}
+	return fmt.Sprintf("spec:%d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+// specInitializers lists the weight and bias initializers accepted in a spec.
+var specInitializers = map[string]bool{
+	"zeros": true, "ones": true, "constant": true, "uniform": true, "normal": true,
+	"xavier_uniform": true, "glorot_uniform": true, "xavier_normal": true, "glorot_normal": true,
+	"he_uniform": true, "he_normal": true, "phase_default": true,
+}
+
+// LoadSpecFile reads and compiles an architecture spec file.
+func LoadSpecFile(path string) (*Phase, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec '%s': %w", path, err)
+	}
+	bp, err := CompileSpec(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return bp, nil
+}
+
+// CompileSpec parses and compiles an architecture spec into a Phase.
+func CompileSpec(data []byte) (*Phase, error) {
+	spec, err := ParseSpec(data)
+	if err != nil {
+		return nil, err
+	}
+	return spec.Compile()
+}
+
+// ParseSpec parses an architecture spec, rejecting unknown keys.
+func ParseSpec(data []byte) (*ArchitectureSpec, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, &SpecError{Msg: err.Error()}
+	}
+	if len(doc.Content) == 0 {
+		return nil, &SpecError{Msg: "empty spec"}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, specErrorAt(root, "spec must be a mapping")
+	}
+
+	spec := &ArchitectureSpec{}
+	err := walkSpecMapping(root, func(key string, value *yaml.Node) error {
+		switch key {
+		case "name":
+			return decodeSpecValue(value, &spec.Name)
+		case "seed":
+			return decodeSpecValue(value, &spec.Seed)
+		case "groups":
+			if value.Kind != yaml.SequenceNode {
+				return specErrorAt(value, "groups must be a list")
+			}
+			for _, item := range value.Content {
+				group := GroupSpec{}
+				lines, err := decodeSpecItem(item, &group, []string{
+					"name", "size", "type", "activation", "output", "bias_init", "bias_value",
+					"dropout_rate", "kernels", "kernel_size",
+				})
+				if err != nil {
+					return err
+				}
+				group.lines = lines
+				spec.Groups = append(spec.Groups, group)
+			}
+			return nil
+		case "connections":
+			if value.Kind != yaml.SequenceNode {
+				return specErrorAt(value, "connections must be a list")
+			}
+			for _, item := range value.Content {
+				conn := ConnectionSpec{}
+				lines, err := decodeSpecItem(item, &conn, []string{
+					"from", "to", "pattern", "density", "kernel", "stride", "init", "init_value",
+				})
+				if err != nil {
+					return err
+				}
+				conn.lines = lines
+				spec.Connections = append(spec.Connections, conn)
+			}
+			return nil
+		}
+		return nil
+	}, []string{"name", "seed", "groups", "connections"})
+	if err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// Compile builds a Phase from the spec. Errors point at the offending line of the spec.
+func (s *ArchitectureSpec) Compile() (*Phase, error) {
+	if len(s.Groups) == 0 {
+		return nil, &SpecError{Msg: "spec defines no groups"}
+	}
+
+	rng := rand.New(rand.NewSource(s.Seed))
+	bp := NewPhase()
+	s.groupIDs = make(map[string][]int)
+	groupIndex := make(map[string]int)
+	neuronID := 0
+
+	// 1) Create every group's neurons with contiguous IDs.
+	for gi := range s.Groups {
+		g := &s.Groups[gi]
+		if g.Name == "" {
+			return nil, g.errorAt("name", "group needs a name")
+		}
+		if _, dup := groupIndex[g.Name]; dup {
+			return nil, g.errorAt("name", "duplicate group name '%s'", g.Name)
+		}
+		if g.Size <= 0 {
+			return nil, g.errorAt("size", "group '%s' must have a positive size", g.Name)
+		}
+		if g.Type == "" {
+			g.Type = "dense"
+		}
+		if !knownNeuronTypes[g.Type] {
+			return nil, g.errorAt("type", "unknown neuron type '%s'", g.Type)
+		}
+		if g.Activation == "" {
+			g.Activation = "linear"
+		}
+		if _, ok := scalarActivationFunctions[g.Activation]; !ok && g.Activation != "softmax" {
+			return nil, g.errorAt("activation", "unknown activation '%s'", g.Activation)
+		}
+		if g.Type == "input" && g.Output {
+			return nil, g.errorAt("output", "input group '%s' cannot be an output", g.Name)
+		}
+		if g.BiasInit == "" {
+			g.BiasInit = "zeros"
+		}
+		if !specInitializers[g.BiasInit] {
+			return nil, g.errorAt("bias_init", "unknown initializer '%s'", g.BiasInit)
+		}
+		groupIndex[g.Name] = gi
+
+		for i := 0; i < g.Size; i++ {
+			neuron := &Neuron{ID: neuronID, Type: g.Type}
+			if g.Type == "input" {
+				bp.InputNodes = append(bp.InputNodes, neuronID)
+			} else {
+				neuron.Activation = g.Activation
+				neuron.Bias = specInitValue(rng, g.BiasInit, g.BiasValue, 1, 1)
+				s.initGroupNeuron(rng, g, neuron)
+			}
+			if g.Output {
+				bp.OutputNodes = append(bp.OutputNodes, neuronID)
+			}
+			bp.Neurons[neuronID] = neuron
+			s.groupIDs[g.Name] = append(s.groupIDs[g.Name], neuronID)
+			neuronID++
+		}
+	}
+	if len(bp.InputNodes) == 0 {
+		return nil, &SpecError{Msg: "spec defines no input group"}
+	}
+	if len(bp.OutputNodes) == 0 {
+		return nil, &SpecError{Msg: "spec defines no output group"}
+	}
+
+	// 2) Wire the groups together.
+	for ci := range s.Connections {
+		c := &s.Connections[ci]
+		fromIdx, ok := groupIndex[c.From]
+		if !ok {
+			return nil, c.errorAt("from", "unknown group '%s'", c.From)
+		}
+		toIdx, ok := groupIndex[c.To]
+		if !ok {
+			return nil, c.errorAt("to", "unknown group '%s'", c.To)
+		}
+		from, to := &s.Groups[fromIdx], &s.Groups[toIdx]
+		if to.Type == "input" {
+			return nil, c.errorAt("to", "cannot connect into input group '%s'", c.To)
+		}
+		recurrent := to.Type == "rnn" || to.Type == "lstm"
+		if fromIdx >= toIdx && !recurrent {
+			return nil, c.errorAt("from", "connection from '%s' to '%s' goes backwards; declare groups in feed-forward order", c.From, c.To)
+		}
+		if from.Output && !to.Output {
+			return nil, c.errorAt("from", "output group '%s' cannot feed hidden group '%s'", c.From, c.To)
+		}
+		if c.Init == "" {
+			c.Init = "phase_default"
+		}
+		if !specInitializers[c.Init] {
+			return nil, c.errorAt("init", "unknown initializer '%s'", c.Init)
+		}
+
+		pairs, err := c.pairs(rng, from.Size, to.Size)
+		if err != nil {
+			return nil, err
+		}
+
+		fanIn := make(map[int]int)
+		fanOut := make(map[int]int)
+		for _, p := range pairs {
+			fanOut[p[0]]++
+			fanIn[p[1]]++
+		}
+		fromIDs, toIDs := s.groupIDs[c.From], s.groupIDs[c.To]
+		for _, p := range pairs {
+			srcID, dstID := fromIDs[p[0]], toIDs[p[1]]
+			if bp.connectionExists(srcID, dstID) {
+				return nil, c.errorAt("from", "connection %d -> %d is already defined by an earlier entry", srcID, dstID)
+			}
+			w := specInitValue(rng, c.Init, c.InitValue, fanIn[p[1]], fanOut[p[0]])
+			bp.Neurons[dstID].Connections = append(bp.Neurons[dstID].Connections, NewConnection(srcID, w))
+		}
+	}
+
+	// 3) Size LSTM gates to the final connection count.
+	for _, id := range bp.sortedNeuronIDs() {
+		neuron := bp.Neurons[id]
+		if neuron.Type != "lstm" {
+			continue
+		}
+		conCount := len(neuron.Connections)
+		neuron.GateWeights = map[string][]float64{}
+		for _, gate := range []string{"input", "forget", "output", "cell"} {
+			weights := make([]float64, conCount)
+			for i := range weights {
+				weights[i] = rng.NormFloat64() * 0.5
+			}
+			neuron.GateWeights[gate] = weights
+		}
+	}
+
+	if bp.Debug {
+		fmt.Printf("Compiled spec '%s': %d neurons, inputs=%v, outputs=%v\n", s.Name, len(bp.Neurons), bp.InputNodes, bp.OutputNodes)
+	}
+	return bp, nil
+}
+
+// GroupIDs returns the neuron IDs assigned to a group by the last Compile.
+func (s *ArchitectureSpec) GroupIDs(name string) []int {
+	return s.groupIDs[name]
+}
+
+// initGroupNeuron sets the type-specific fields of a freshly created group neuron.
+func (s *ArchitectureSpec) initGroupNeuron(rng *rand.Rand, g *GroupSpec, neuron *Neuron) {
+	switch g.Type {
+	case "cnn":
+		numKernels, kernelSize := g.Kernels, g.KernelSize
+		if numKernels <= 0 {
+			numKernels = 2
+		}
+		if kernelSize <= 0 {
+			kernelSize = 2
+		}
+		neuron.Kernels = make([][]float64, numKernels)
+		for k := range neuron.Kernels {
+			neuron.Kernels[k] = make([]float64, kernelSize)
+			for j := range neuron.Kernels[k] {
+				neuron.Kernels[k][j] = rng.Float64()
+			}
+		}
+	case "batch_norm":
+		neuron.BatchNormParams = &BatchNormParams{Gamma: 1.0, Beta: 0.0, Mean: 0.0, Var: 1.0, SumInputs: true}
+	case "dropout":
+		neuron.DropoutRate = g.DropoutRate
+		if neuron.DropoutRate == 0 {
+			neuron.DropoutRate = 0.5
+		}
+	}
+}
+
+// pairs returns the (source index, target index) pairs within the two groups for this connection.
+func (c *ConnectionSpec) pairs(rng *rand.Rand, fromSize, toSize int) ([][2]int, error) {
+	pairs := [][2]int{}
+	switch c.Pattern {
+	case "", "full":
+		for t := 0; t < toSize; t++ {
+			for f := 0; f < fromSize; f++ {
+				pairs = append(pairs, [2]int{f, t})
+			}
+		}
+	case "sparse":
+		if c.Density <= 0 || c.Density > 1 {
+			return nil, c.errorAt("density", "sparse connections need a density in (0, 1], got %g", c.Density)
+		}
+		for t := 0; t < toSize; t++ {
+			kept := 0
+			for f := 0; f < fromSize; f++ {
+				if rng.Float64() < c.Density {
+					pairs = append(pairs, [2]int{f, t})
+					kept++
+				}
+			}
+			// Every target keeps at least one source so no neuron is left dangling.
+			if kept == 0 {
+				pairs = append(pairs, [2]int{rng.Intn(fromSize), t})
+			}
+		}
+	case "one_to_one":
+		if fromSize != toSize {
+			return nil, c.errorAt("pattern", "one_to_one needs groups of equal size, got %d and %d", fromSize, toSize)
+		}
+		for i := 0; i < toSize; i++ {
+			pairs = append(pairs, [2]int{i, i})
+		}
+	case "conv":
+		stride := c.Stride
+		if stride <= 0 {
+			stride = 1
+		}
+		if c.Kernel <= 0 || c.Kernel > fromSize {
+			return nil, c.errorAt("kernel", "conv kernel must be in [1, %d], got %d", fromSize, c.Kernel)
+		}
+		expected := (fromSize-c.Kernel)/stride + 1
+		if toSize != expected {
+			return nil, c.errorAt("to", "conv with kernel %d and stride %d over %d neurons needs a target of size %d, got %d",
+				c.Kernel, stride, fromSize, expected, toSize)
+		}
+		for t := 0; t < toSize; t++ {
+			for k := 0; k < c.Kernel; k++ {
+				pairs = append(pairs, [2]int{t*stride + k, t})
+			}
+		}
+	default:
+		return nil, c.errorAt("pattern", "unknown connection pattern '%s'", c.Pattern)
+	}
+	return pairs, nil
+}
+
+// specInitValue draws a single value from the named initializer.
+func specInitValue(rng *rand.Rand, name string, param float64, fanIn, fanOut int) float64 {
+	fi, fo := float64(fanIn), float64(fanOut)
+	if fi < 1 {
+		fi = 1
+	}
+	if fo < 1 {
+		fo = 1
+	}
+	switch name {
+	case "zeros":
+		return 0
+	case "ones":
+		return 1
+	case "constant":
+		return param
+	case "uniform":
+		limit := param
+		if limit == 0 {
+			limit = 0.05
+		}
+		return (rng.Float64()*2 - 1) * limit
+	case "normal":
+		std := param
+		if std == 0 {
+			std = 0.05
+		}
+		return rng.NormFloat64() * std
+	case "xavier_uniform", "glorot_uniform":
+		limit := math.Sqrt(6 / (fi + fo))
+		return (rng.Float64()*2 - 1) * limit
+	case "xavier_normal", "glorot_normal":
+		return rng.NormFloat64() * math.Sqrt(2/(fi+fo))
+	case "he_uniform":
+		limit := math.Sqrt(6 / fi)
+		return (rng.Float64()*2 - 1) * limit
+	case "he_normal":
+		return rng.NormFloat64() * math.Sqrt(2/fi)
+	default: // phase_default matches NewPhaseWithLayers
+		return rng.Float64()*2 - 1
+	}
+}
+
+// walkSpecMapping calls fn for every key of a mapping node after checking it against the allowed keys.
+func walkSpecMapping(node *yaml.Node, fn func(key string, value *yaml.Node) error, allowed []string) error {
+	if node.Kind != yaml.MappingNode {
+		return specErrorAt(node, "expected a mapping")
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		known := false
+		for _, k := range allowed {
+			if k == keyNode.Value {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return specErrorAt(keyNode, "unknown key '%s'", keyNode.Value)
+		}
+		if err := fn(keyNode.Value, valueNode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeSpecItem decodes a group or connection entry and records the line of every key.
+func decodeSpecItem(node *yaml.Node, out interface{}, allowed []string) (map[string]int, error) {
+	lines := map[string]int{"": node.Line, "#col": node.Column}
+	err := walkSpecMapping(node, func(key string, value *yaml.Node) error {
+		lines[key] = value.Line
+		lines[key+"#col"] = value.Column
+		return nil
+	}, allowed)
+	if err != nil {
+		return nil, err
+	}
+	if err := node.Decode(out); err != nil {
+		return nil, specErrorAt(node, "%v", err)
+	}
+	return lines, nil
+}
+
+// decodeSpecValue decodes a scalar node, reporting failures at the node's position.
+func decodeSpecValue(node *yaml.Node, out interface{}) error {
+	if err := node.Decode(out); err != nil {
+		return specErrorAt(node, "%v", err)
+	}
+	return nil
+}
+
+// specErrorAt builds a SpecError positioned at node.
+func specErrorAt(node *yaml.Node, format string, args ...interface{}) *SpecError {
+	return &SpecError{Line: node.Line, Column: node.Column, Msg: fmt.Sprintf(format, args...)}
+}
+
+// specLineError builds a SpecError from the recorded line of a key, falling back to the entry itself.
+func specLineError(lines map[string]int, key string, format string, args ...interface{}) *SpecError {
+	line, ok := lines[key]
+	col := lines[key+"#col"]
+	if !ok {
+		line, col = lines[""], lines["#col"]
+	}
+	return &SpecError{Line: line, Column: col, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (g *GroupSpec) errorAt(key, format string, args ...interface{}) *SpecError {
+	return specLineError(g.lines, key, format, args...)
+}
+
+func (c *ConnectionSpec) errorAt(key, format string, args ...interface{}) *SpecError {
+	return specLineError(c.lines, key, format, args...)
+}
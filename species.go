@@ -3,6 +3,8 @@ package phase
 import (
 	"fmt"
 	"math"
+	"math/rand"
+	"sort"
 )
 
 // PhaseSimilarity computes a similarity percentage (0–100) between two Phases.
@@ -37,14 +39,14 @@ func PhaseSimilarity(bp1, bp2 *Phase) float64 {
 			totalSim += biasSim
 			count++
 
-			// Compare connection weights for common connections.
-			commonConns := len(neuron1.Connections)
-			if len(neuron2.Connections) < commonConns {
-				commonConns = len(neuron2.Connections)
-			}
-			for i := 0; i < commonConns; i++ {
-				w1 := neuron1.Connections[i][1]
-				w2 := neuron2.Connections[i][1]
+			// Compare connection weights for connections sharing the same source.
+			for _, conn1 := range neuron1.Connections {
+				j := connectionIndex(neuron2.Connections, conn1.Source)
+				if j < 0 {
+					continue
+				}
+				w1 := conn1.Weight
+				w2 := neuron2.Connections[j].Weight
 				weightDenom := math.Abs(w1) + math.Abs(w2) + 1e-7
 				weightSim := 1.0 - math.Abs(w1-w2)/weightDenom
 				totalSim += weightSim
@@ -95,6 +97,7 @@ func PhaseSimilarity(bp1, bp2 *Phase) float64 {
 // Two Phases are considered similar (and thus in the same species) if their similarity is
 // greater than or equal to similarityThreshold. The function returns a map where the key is a species ID
 // and the value is a slice of Phase IDs belonging to that species.
+// Clusters merge transitively; see Speciator for persistent NEAT-style species.
 func ClusterPhasesBySpecies(Phases map[int]*Phase, similarityThreshold float64) map[int][]int {
 	// Initialize union-find structure: each Phase starts in its own set.
 	parent := make(map[int]int)
@@ -149,3 +152,316 @@ func ClusterPhasesBySpecies(Phases map[int]*Phase, similarityThreshold float64)
 
 	return clusters
 }
+
+// CompatibilityConfig holds the coefficients of the NEAT compatibility distance.
+type CompatibilityConfig struct {
+	ExcessCoeff   float64 // c1: weight of excess genes
+	DisjointCoeff float64 // c2: weight of disjoint genes
+	WeightCoeff   float64 // c3: weight of the mean weight difference of matching genes
+	SmallGenome   int     // Genomes with fewer genes than this are not size-normalized
+}
+
+// DefaultCompatibilityConfig returns the coefficients from the original NEAT paper.
+func DefaultCompatibilityConfig() CompatibilityConfig {
+	return CompatibilityConfig{ExcessCoeff: 1.0, DisjointCoeff: 1.0, WeightCoeff: 0.4, SmallGenome: 20}
+}
+
+// CompatibilityDistance computes the NEAT distance c1*E/N + c2*D/N + c3*W between two Phases,
+// where E and D count excess and disjoint connection genes, W is the mean absolute weight
+// difference of matching genes and N is the size of the larger genome. It only reads the
+// Innovations tracker; see phaseGenes for connections that carry no innovation number.
+func CompatibilityDistance(a, b *Phase, cfg CompatibilityConfig) float64 {
+	genesA, genesB := phaseGenes(a, b)
+	maxA, maxB := maxInnovation(genesA), maxInnovation(genesB)
+
+	excess, disjoint, matching := 0, 0, 0
+	weightDiff := 0.0
+	for _, innovation := range sortedGeneInnovations(genesA) {
+		geneA := genesA[innovation]
+		if geneB, ok := genesB[innovation]; ok {
+			matching++
+			weightDiff += math.Abs(geneA.Weight - geneB.Weight)
+		} else if innovation > maxB {
+			excess++
+		} else {
+			disjoint++
+		}
+	}
+	for innovation := range genesB {
+		if _, ok := genesA[innovation]; ok {
+			continue
+		}
+		if innovation > maxA {
+			excess++
+		} else {
+			disjoint++
+		}
+	}
+
+	n := math.Max(float64(len(genesA)), float64(len(genesB)))
+	if n < float64(cfg.SmallGenome) || n == 0 {
+		n = 1
+	}
+	meanWeightDiff := 0.0
+	if matching > 0 {
+		meanWeightDiff = weightDiff / float64(matching)
+	}
+	return cfg.ExcessCoeff*float64(excess)/n + cfg.DisjointCoeff*float64(disjoint)/n + cfg.WeightCoeff*meanWeightDiff
+}
+
+// phaseGenes indexes every connection of a and b by innovation number without assigning any.
+// A connection with no number of its own takes the tracker's number for its pair. Pairs the
+// tracker has never seen get the numbers AssignInnovations would give them next, in order of
+// target and then source, so they match across the two Phases and count as the newest genes.
+func phaseGenes(a, b *Phase) (map[int]Connection, map[int]Connection) {
+	unseen := make(map[[2]int]int) // (source, target) -> provisional innovation
+	next := 0
+	for _, bp := range []*Phase{a, b} {
+		for _, id := range bp.sortedNeuronIDs() {
+			for _, conn := range bp.Neurons[id].Connections {
+				if conn.Innovation != 0 {
+					continue
+				}
+				if pending, known := Innovations.lookup(conn.Source, id); !known {
+					unseen[[2]int{conn.Source, id}], next = 0, pending
+				}
+			}
+		}
+	}
+	pairs := make([][2]int, 0, len(unseen))
+	for pair := range unseen {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][1] != pairs[j][1] {
+			return pairs[i][1] < pairs[j][1]
+		}
+		return pairs[i][0] < pairs[j][0]
+	})
+	for i, pair := range pairs {
+		unseen[pair] = next + i
+	}
+
+	index := func(bp *Phase) map[int]Connection {
+		genes := make(map[int]Connection)
+		for _, id := range bp.sortedNeuronIDs() {
+			for _, conn := range bp.Neurons[id].Connections {
+				innovation := conn.Innovation
+				if innovation == 0 {
+					var known bool
+					if innovation, known = Innovations.lookup(conn.Source, id); !known {
+						innovation = unseen[[2]int{conn.Source, id}]
+					}
+				}
+				genes[innovation] = conn
+			}
+		}
+		return genes
+	}
+	return index(a), index(b)
+}
+
+// maxInnovation returns the highest innovation number among genes, or 0.
+func maxInnovation(genes map[int]Connection) int {
+	highest := 0
+	for innovation := range genes {
+		if innovation > highest {
+			highest = innovation
+		}
+	}
+	return highest
+}
+
+// SpeciationConfig configures a Speciator.
+type SpeciationConfig struct {
+	Compatibility   CompatibilityConfig
+	Threshold       float64 // Initial compatibility threshold (default 3.0)
+	TargetSpecies   int     // Adjust the threshold each generation towards this many species; 0 keeps it fixed
+	ThresholdStep   float64 // Threshold change per generation (default 0.3)
+	MinThreshold    float64 // Lower bound of the threshold (default 0.3)
+	StagnationLimit int     // Generations without improvement before a species goes extinct; 0 disables
+	SpeciesElitism  int     // Number of best species protected from extinction
+}
+
+// DefaultSpeciationConfig returns NEAT defaults with extinction after 15 stagnant generations.
+func DefaultSpeciationConfig() SpeciationConfig {
+	return SpeciationConfig{
+		Compatibility:   DefaultCompatibilityConfig(),
+		Threshold:       3.0,
+		ThresholdStep:   0.3,
+		MinThreshold:    0.3,
+		StagnationLimit: 15,
+		SpeciesElitism:  2,
+	}
+}
+
+// Species is a group of compatible individuals that persists across generations.
+type Species struct {
+	ID             int
+	Representative *Phase        // Individuals within the threshold of this Phase join the species
+	Members        []*Individual // Members of the current generation
+	Age            int           // Generations the species has existed
+	BestFitness    float64       // Best raw fitness any member has reached
+	Stagnation     int           // Generations since BestFitness improved
+	SharedFitness  float64       // Sum of the members' adjusted fitness
+	Offspring      int           // Offspring allocated for the next generation
+}
+
+// Speciator assigns individuals to persistent species and applies explicit fitness sharing.
+type Speciator struct {
+	Config    SpeciationConfig
+	Species   []*Species
+	Threshold float64 // Current compatibility threshold
+	nextID    int
+}
+
+// NewSpeciator returns a Speciator with no species.
+func NewSpeciator(cfg SpeciationConfig) *Speciator {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = 3.0
+	}
+	if cfg.ThresholdStep <= 0 {
+		cfg.ThresholdStep = 0.3
+	}
+	if cfg.MinThreshold <= 0 {
+		cfg.MinThreshold = 0.3
+	}
+	return &Speciator{Config: cfg, Threshold: cfg.Threshold, nextID: 1}
+}
+
+// Speciate assigns evaluated individuals to species. Each individual joins the first species
+// whose representative is within the threshold, or founds a new one. Empty species are dropped,
+// every surviving species picks a random member as its next representative, and the threshold
+// is moved towards TargetSpecies.
+func (s *Speciator) Speciate(individuals []*Individual, rng *rand.Rand) {
+	for _, sp := range s.Species {
+		sp.Members = sp.Members[:0]
+	}
+	for _, ind := range individuals {
+		var home *Species
+		for _, sp := range s.Species {
+			if CompatibilityDistance(ind.BP, sp.Representative, s.Config.Compatibility) < s.Threshold {
+				home = sp
+				break
+			}
+		}
+		if home == nil {
+			home = &Species{ID: s.nextID, Representative: ind.BP, BestFitness: math.Inf(-1)}
+			s.nextID++
+			s.Species = append(s.Species, home)
+		}
+		home.Members = append(home.Members, ind)
+		ind.SpeciesID = home.ID
+	}
+
+	alive := s.Species[:0]
+	for _, sp := range s.Species {
+		if len(sp.Members) == 0 {
+			continue
+		}
+		sp.Age++
+		best := math.Inf(-1)
+		for _, ind := range sp.Members {
+			best = math.Max(best, ind.Fitness)
+		}
+		if best > sp.BestFitness {
+			sp.BestFitness = best
+			sp.Stagnation = 0
+		} else {
+			sp.Stagnation++
+		}
+		sp.Representative = sp.Members[rng.Intn(len(sp.Members))].BP
+		alive = append(alive, sp)
+	}
+	s.Species = alive
+
+	if target := s.Config.TargetSpecies; target > 0 {
+		if len(s.Species) < target {
+			s.Threshold = math.Max(s.Config.MinThreshold, s.Threshold-s.Config.ThresholdStep)
+		} else if len(s.Species) > target {
+			s.Threshold += s.Config.ThresholdStep
+		}
+	}
+}
+
+// ShareFitness sets each member's AdjustedFitness to its fitness, rescaled to [0, 1] across
+// the population, divided by the size of its species.
+func (s *Speciator) ShareFitness() {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, sp := range s.Species {
+		for _, ind := range sp.Members {
+			low = math.Min(low, ind.Fitness)
+			high = math.Max(high, ind.Fitness)
+		}
+	}
+	for _, sp := range s.Species {
+		sp.SharedFitness = 0
+		for _, ind := range sp.Members {
+			scaled := 1.0
+			if high > low {
+				scaled = (ind.Fitness - low) / (high - low)
+			}
+			ind.AdjustedFitness = scaled / float64(len(sp.Members))
+			sp.SharedFitness += ind.AdjustedFitness
+		}
+	}
+}
+
+// RemoveStagnant drops species that have not improved for StagnationLimit generations and
+// returns them. The SpeciesElitism best species are kept, and at least one species always survives.
+func (s *Speciator) RemoveStagnant() []*Species {
+	if s.Config.StagnationLimit <= 0 || len(s.Species) == 0 {
+		return nil
+	}
+	ranked := append([]*Species{}, s.Species...)
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].BestFitness > ranked[j].BestFitness })
+	protected := make(map[int]bool)
+	for i := 0; i < len(ranked) && (i < s.Config.SpeciesElitism || i == 0); i++ {
+		protected[ranked[i].ID] = true
+	}
+
+	extinct := []*Species{}
+	alive := s.Species[:0]
+	for _, sp := range s.Species {
+		if sp.Stagnation >= s.Config.StagnationLimit && !protected[sp.ID] {
+			extinct = append(extinct, sp)
+			continue
+		}
+		alive = append(alive, sp)
+	}
+	s.Species = alive
+	return extinct
+}
+
+// AllocateOffspring splits total offspring between the species in proportion to their
+// shared fitness, using the largest remainder method, and stores the result in Offspring.
+func (s *Speciator) AllocateOffspring(total int) {
+	if len(s.Species) == 0 {
+		return
+	}
+	sum := 0.0
+	for _, sp := range s.Species {
+		sum += sp.SharedFitness
+	}
+
+	type remainder struct {
+		sp   *Species
+		frac float64
+	}
+	remainders := make([]remainder, len(s.Species))
+	assigned := 0
+	for i, sp := range s.Species {
+		share := float64(total) / float64(len(s.Species))
+		if sum > 0 {
+			share = float64(total) * sp.SharedFitness / sum
+		}
+		sp.Offspring = int(share)
+		assigned += sp.Offspring
+		remainders[i] = remainder{sp, share - float64(sp.Offspring)}
+	}
+	sort.SliceStable(remainders, func(i, j int) bool { return remainders[i].frac > remainders[j].frac })
+	for i := 0; assigned < total; i = (i + 1) % len(remainders) {
+		remainders[i].sp.Offspring++
+		assigned++
+	}
+}
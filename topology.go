@@ -0,0 +1,93 @@
+package phase
+
+import "sort"
+
+// InferLayers groups neurons into feed-forward layers.
+// Input neurons form layer 0 and every other neuron sits one layer after its deepest source.
+// Connections that close a cycle (recurrent edges) are ignored, and output neurons are
+// always placed in the last layer. Each layer is sorted by neuron ID.
+func (bp *Phase) InferLayers() [][]int {
+	depth := bp.inferDepths()
+
+	maxDepth := 0
+	for _, d := range depth {
+		if d > maxDepth {
+			maxDepth = d
+		}
+	}
+	for _, id := range bp.OutputNodes {
+		if _, exists := depth[id]; exists {
+			depth[id] = maxDepth
+		}
+	}
+
+	layers := make([][]int, maxDepth+1)
+	for _, id := range bp.sortedNeuronIDs() {
+		d := depth[id]
+		layers[d] = append(layers[d], id)
+	}
+
+	// Drop layers left empty after moving the outputs.
+	compacted := layers[:0]
+	for _, layer := range layers {
+		if len(layer) > 0 {
+			compacted = append(compacted, layer)
+		}
+	}
+	return compacted
+}
+
+// inferDepths returns the longest-path depth of every neuron from the inputs.
+func (bp *Phase) inferDepths() map[int]int {
+	depth := make(map[int]int, len(bp.Neurons))
+	onStack := make(map[int]bool)
+
+	var visit func(id int) int
+	visit = func(id int) int {
+		if d, done := depth[id]; done {
+			return d
+		}
+		neuron := bp.Neurons[id]
+		if neuron.Type == "input" {
+			depth[id] = 0
+			return 0
+		}
+		onStack[id] = true
+		d := 1
+		for _, conn := range neuron.Connections {
+			srcID := conn.Source
+			if _, exists := bp.Neurons[srcID]; !exists || !conn.Enabled || onStack[srcID] {
+				continue
+			}
+			if sd := visit(srcID) + 1; sd > d {
+				d = sd
+			}
+		}
+		onStack[id] = false
+		depth[id] = d
+		return d
+	}
+
+	for _, id := range bp.sortedNeuronIDs() {
+		visit(id)
+	}
+	return depth
+}
+
+// evaluationOrder returns the neuron IDs sorted by inferred depth, ties broken by ID, so each
+// neuron comes after the sources feeding it over enabled, non-recurrent connections. Neurons
+// inserted by SplitConnection or InsertHiddenLayer get high IDs but still run before the
+// neurons they feed.
+func (bp *Phase) evaluationOrder() []int {
+	depth := bp.inferDepths()
+	ids := bp.sortedNeuronIDs()
+	sort.SliceStable(ids, func(i, j int) bool { return depth[ids[i]] < depth[ids[j]] })
+	return ids
+}
+
+// sortedNeuronIDs returns the IDs of all neurons in ascending order.
+func (bp *Phase) sortedNeuronIDs() []int {
+	ids := bp.getAllNeuronIDs()
+	sort.Ints(ids)
+	return ids
+}
@@ -1,6 +1,7 @@
 package phase
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"math/rand"
@@ -17,6 +18,7 @@ func (bp *Phase) TrainNetwork(inputs map[int]float64, expectedOutputs map[int]fl
 	if learningRate <= 0 || learningRate > 0.1 {
 		learningRate = 0.001 // Default to a small, stable value
 	}
+	bp.recordTraining("TrainNetwork", learningRate)
 
 	// Forward pass
 	bp.Forward(inputs, 1)
@@ -28,8 +30,9 @@ func (bp *Phase) TrainNetwork(inputs map[int]float64, expectedOutputs map[int]fl
 		outputErrors[id] = expected - actual
 	}
 
-	// Backward pass
-	for id, neuron := range bp.Neurons {
+	// Backward pass, in ID order so that repeated runs update the weights identically
+	for _, id := range bp.sortedNeuronIDs() {
+		neuron := bp.Neurons[id]
 		if neuron.Type == "input" {
 			continue
 		}
@@ -48,16 +51,19 @@ func (bp *Phase) TrainNetwork(inputs map[int]float64, expectedOutputs map[int]fl
 
 		// Update weights and bias with NaN checks and clamping
 		for i, conn := range neuron.Connections {
-			sourceID := int(conn[0])
+			if !conn.Enabled {
+				continue
+			}
+			sourceID := conn.Source
 			sourceValue := bp.Neurons[sourceID].Value
 			gradient := errorTerm * sourceValue
 			if !math.IsNaN(gradient) && !math.IsInf(gradient, 0) {
-				neuron.Connections[i][1] += learningRate * gradient
+				neuron.Connections[i].Weight += learningRate * gradient
 				// Clamp weight to [-5, 5]
-				if neuron.Connections[i][1] > clampMax {
-					neuron.Connections[i][1] = clampMax
-				} else if neuron.Connections[i][1] < clampMin {
-					neuron.Connections[i][1] = clampMin
+				if neuron.Connections[i].Weight > clampMax {
+					neuron.Connections[i].Weight = clampMax
+				} else if neuron.Connections[i].Weight < clampMin {
+					neuron.Connections[i].Weight = clampMin
 				}
 			}
 		}
@@ -107,9 +113,10 @@ func (bp *Phase) activationDerivative(value float64, activation string) float64
 // getDownstreamNeurons finds neurons that depend on the given neuron.
 func (bp *Phase) getDownstreamNeurons(neuronID int) []int {
 	downstream := []int{}
-	for id, neuron := range bp.Neurons {
+	for _, id := range bp.sortedNeuronIDs() { // Stable order keeps seeded runs reproducible
+		neuron := bp.Neurons[id]
 		for _, conn := range neuron.Connections {
-			if int(conn[0]) == neuronID {
+			if conn.Source == neuronID {
 				downstream = append(downstream, id)
 				break
 			}
@@ -122,8 +129,8 @@ func (bp *Phase) getDownstreamNeurons(neuronID int) []int {
 func (bp *Phase) getWeight(sourceID, targetID int) float64 {
 	targetNeuron := bp.Neurons[targetID]
 	for _, conn := range targetNeuron.Connections {
-		if int(conn[0]) == sourceID {
-			return conn[1]
+		if conn.Source == sourceID {
+			return conn.effectiveWeight()
 		}
 	}
 	return 0
@@ -134,6 +141,7 @@ func (bp *Phase) TrainNetworkTargeted(inputs map[int]float64, expectedOutputs ma
 	if learningRate <= 0 || learningRate > 0.1 {
 		learningRate = 0.001 // Default to a small, stable value
 	}
+	bp.recordTraining("TrainNetworkTargeted", learningRate)
 
 	// Forward pass
 	bp.Forward(inputs, 1)
@@ -151,8 +159,9 @@ func (bp *Phase) TrainNetworkTargeted(inputs map[int]float64, expectedOutputs ma
 		trainableSet[id] = struct{}{}
 	}
 
-	// Backward pass
-	for id, neuron := range bp.Neurons {
+	// Backward pass, in ID order so that seeded runs repeat
+	for _, id := range bp.sortedNeuronIDs() {
+		neuron := bp.Neurons[id]
 		if neuron.Type == "input" {
 			continue
 		}
@@ -175,16 +184,19 @@ func (bp *Phase) TrainNetworkTargeted(inputs map[int]float64, expectedOutputs ma
 		if _, isTrainable := trainableSet[id]; isTrainable {
 			// Update weights
 			for i, conn := range neuron.Connections {
-				sourceID := int(conn[0])
+				if !conn.Enabled {
+					continue
+				}
+				sourceID := conn.Source
 				sourceValue := bp.Neurons[sourceID].Value
 				gradient := errorTerm * sourceValue
 				if !math.IsNaN(gradient) && !math.IsInf(gradient, 0) {
-					neuron.Connections[i][1] += learningRate * gradient
+					neuron.Connections[i].Weight += learningRate * gradient
 					// Clamp weight
-					if neuron.Connections[i][1] > clampMax {
-						neuron.Connections[i][1] = clampMax
-					} else if neuron.Connections[i][1] < clampMin {
-						neuron.Connections[i][1] = clampMin
+					if neuron.Connections[i].Weight > clampMax {
+						neuron.Connections[i].Weight = clampMax
+					} else if neuron.Connections[i].Weight < clampMin {
+						neuron.Connections[i].Weight = clampMin
 					}
 				}
 			}
@@ -203,80 +215,254 @@ func (bp *Phase) TrainNetworkTargeted(inputs map[int]float64, expectedOutputs ma
 	}
 }
 
+// GrowConfig configures GrowWithConfig.
+type GrowConfig struct {
+	MinNeuronsToAdd        int     // Neurons added per iteration, at least
+	MaxNeuronsToAdd        int     // Neurons added per iteration, at most
+	EvalWithMultiCore      bool    // Evaluate candidates with EvaluateWithCheckpointsMultiCore
+	CheckpointFolder       string  // Load checkpoints from this folder instead of memory when set
+	WorkerID               int     // Sandbox number shown in progress messages
+	MaxIterations          int     // Candidates to try in total
+	MaxConsecutiveFailures int     // Stop after this many rejected candidates in a row
+	MinConnections         int     // Incoming connections of each new neuron, at least
+	MaxConnections         int     // Incoming connections of each new neuron, at most
+	Epsilon                float64 // Reserved for tolerance-based acceptance
+
+	// Novelty switches acceptance to a blend of improvement and behavioral novelty; nil uses
+	// improvement alone.
+	Novelty *NoveltyConfig `json:"-"`
+
+	// Modes lists the kinds of growth each step picks from at random; nil grows width only.
+	Modes []GrowthMode
+
+	// NeuronTypes weighs the types of the neurons added by width growth, e.g.
+	// {"dense": 3, "lstm": 1}; nil adds dense neurons only.
+	NeuronTypes map[string]float64
+
+	// Events receives a GrowEvent per iteration, improvement and exit instead of the progress
+	// lines printed to stdout. Sends block until received or the context is done.
+	Events chan<- GrowEvent `json:"-"`
+
+	// Policy compares candidates with the best model; nil uses the receiver's ImprovementPolicy.
+	Policy ImprovementPolicy `json:"-"`
+
+	// Rand supplies the randomness of the growth steps; nil uses the receiver's.
+	Rand *rand.Rand `json:"-"`
+
+	// FineTune, when set, follows each accepted growth step with a short gradient fine-tune
+	// of the subset it selects on the samples, kept only if it improves the model. Scopes
+	// beyond the new neurons change checkpointed neurons, so the run then rebuilds its
+	// checkpoints in memory from the samples.
+	FineTune *FineTuneConfig
+}
+
+// Grow repeatedly adds neurons between the pre-output layer and the outputs of a copy of
+// originalBP, keeping a candidate whenever it improves on the best model so far.
+// GrowWithConfig can also grow deeper and add skip connections; see GrowConfig.Modes.
 func (bp *Phase) Grow(minNeuronsToAdd int, maxNeuronsToAdd int, evalWithMultiCore bool, checkpointFolder string, originalBP *Phase, samples *[]Sample, checkpoints *[]map[int]map[string]interface{}, workerID int, maxIterations int, maxConsecutiveFailures int, minConnections int, maxConnections int, epsilon float64) ModelResult {
-	bestBP := originalBP.Copy()
+	return bp.GrowWithConfig(GrowConfig{
+		MinNeuronsToAdd:        minNeuronsToAdd,
+		MaxNeuronsToAdd:        maxNeuronsToAdd,
+		EvalWithMultiCore:      evalWithMultiCore,
+		CheckpointFolder:       checkpointFolder,
+		WorkerID:               workerID,
+		MaxIterations:          maxIterations,
+		MaxConsecutiveFailures: maxConsecutiveFailures,
+		MinConnections:         minConnections,
+		MaxConnections:         maxConnections,
+		Epsilon:                epsilon,
+	}, originalBP, samples, checkpoints)
+}
 
-	var bestExactAcc float64
-	var bestClosenessBins []float64
-	var bestApproxScore float64
+// GrowWithConfig is Grow driven by a GrowConfig. Candidates are compared with the receiver's
+// ImprovementPolicy, blended with novelty when cfg.Novelty is set.
+func (bp *Phase) GrowWithConfig(cfg GrowConfig, originalBP *Phase, samples *[]Sample, checkpoints *[]map[int]map[string]interface{}) ModelResult {
+	result, _ := bp.GrowWithContext(context.Background(), cfg, originalBP, samples, checkpoints)
+	return result
+}
 
-	if evalWithMultiCore {
-		bestExactAcc, bestClosenessBins, bestApproxScore = bestBP.EvaluateWithCheckpointsMultiCore(checkpointFolder, checkpoints, GetLabels(samples, bestBP.OutputNodes))
-	} else {
-		bestExactAcc, bestClosenessBins, bestApproxScore = bestBP.EvaluateWithCheckpoints(checkpointFolder, checkpoints, GetLabels(samples, bestBP.OutputNodes))
+// GrowWithContext is GrowWithConfig that stops between iterations once ctx is done. It then
+// returns the best model found so far together with ctx.Err().
+func (bp *Phase) GrowWithContext(ctx context.Context, cfg GrowConfig, originalBP *Phase, samples *[]Sample, checkpoints *[]map[int]map[string]interface{}) (ModelResult, error) {
+	rng := cfg.Rand
+	if rng == nil {
+		rng = bp.rng()
 	}
-	bestClosenessQuality := bp.ComputeClosenessQuality(bestClosenessBins)
+	run := newGrowRun(bp.resolvePolicy(cfg.Policy), cfg, rng, samples, checkpoints)
+	best := originalBP.Copy()
+	if best.Rand != nil {
+		// Reseed from the run's source: parallel runs copy originalBP in no fixed order.
+		best.seedRand(rng.Int63())
+	}
+	run.start(best, newGrowthScope(originalBP))
+	for !run.done() && ctx.Err() == nil {
+		run.step(ctx)
+	}
+	run.finish(ctx)
+	return run.result(), ctx.Err()
+}
 
-	consecutiveFailures := 0
-	iterations := 0
-	neuronsAdded := 0
+// growRun holds the state of one Grow run, so GrowSession can save it between iterations and
+// pick up where it left off.
+type growRun struct {
+	policy      ImprovementPolicy // Compares candidates with the best model
+	cfg         GrowConfig
+	rng         *rand.Rand
+	samples     *[]Sample
+	checkpoints *[]map[int]map[string]interface{}
+	novelty     *noveltyState
+	scope       *growthScope
+	rebuilt     bool // checkpoints were rebuilt from the samples; CheckpointFolder no longer applies
+
+	best                *Phase
+	bestExactAcc        float64
+	bestClosenessBins   []float64
+	bestClosenessQ      float64
+	bestApproxScore     float64
+	bestNovelty         float64
+	iterations          int
+	consecutiveFailures int
+	neuronsAdded        int
+}
 
-	for consecutiveFailures < maxConsecutiveFailures && iterations < maxIterations {
-		iterations++
-		currentBP := bestBP.Copy()
-		numToAdd := rand.Intn(maxNeuronsToAdd-minNeuronsToAdd+1) + minNeuronsToAdd
+func newGrowRun(policy ImprovementPolicy, cfg GrowConfig, rng *rand.Rand, samples *[]Sample, checkpoints *[]map[int]map[string]interface{}) *growRun {
+	return &growRun{policy: policy, cfg: cfg, rng: rng, samples: samples, checkpoints: checkpoints}
+}
 
-		for i := 0; i < numToAdd; i++ {
-			newNeuron := currentBP.AddNeuronFromPreOutputs("dense", "", minConnections, maxConnections)
-			if newNeuron != nil {
-				currentBP.AddNewNeuronToOutput(newNeuron.ID)
-				neuronsAdded++
-			}
+// start evaluates the initial model; novelty, if enabled, starts from it too.
+func (r *growRun) start(best *Phase, scope *growthScope) {
+	r.best = best
+	r.scope = scope
+	if r.cfg.FineTune.retrainsOriginal() {
+		// best may have been fine-tuned since the checkpoints were taken.
+		r.checkpoints, r.rebuilt = r.checkpointsFor(best), true
+	}
+	r.bestExactAcc, r.bestClosenessBins, r.bestApproxScore = r.evaluate(best)
+	r.bestClosenessQ = r.policy.ClosenessQuality(r.bestClosenessBins)
+	if r.cfg.Novelty != nil {
+		folder := r.cfg.CheckpointFolder
+		if r.rebuilt {
+			folder = ""
 		}
+		r.novelty = newNoveltyState(*r.cfg.Novelty, folder, *r.checkpoints)
+		r.bestNovelty = r.novelty.score(r.novelty.behavior(best))
+	}
+}
 
-		var newExactAcc float64
-		var newClosenessBins []float64
-		var newApproxScore float64
+func (r *growRun) evaluate(candidate *Phase) (float64, []float64, float64) {
+	folder := r.cfg.CheckpointFolder
+	if r.rebuilt {
+		folder = ""
+	}
+	return r.evaluateWith(candidate, folder, r.checkpoints)
+}
 
-		if evalWithMultiCore {
-			newExactAcc, newClosenessBins, newApproxScore = currentBP.EvaluateWithCheckpointsMultiCore(checkpointFolder, checkpoints, GetLabels(samples, currentBP.OutputNodes))
-		} else {
-			newExactAcc, newClosenessBins, newApproxScore = currentBP.EvaluateWithCheckpoints(checkpointFolder, checkpoints, GetLabels(samples, currentBP.OutputNodes))
-		}
-		newClosenessQuality := currentBP.ComputeClosenessQuality(newClosenessBins)
+func (r *growRun) evaluateWith(candidate *Phase, folder string, checkpoints *[]map[int]map[string]interface{}) (float64, []float64, float64) {
+	labels := GetLabels(r.samples, candidate.OutputNodes)
+	if r.cfg.EvalWithMultiCore {
+		return candidate.EvaluateWithCheckpointsMultiCore(folder, checkpoints, labels)
+	}
+	return candidate.EvaluateWithCheckpoints(folder, checkpoints, labels)
+}
 
-		fmt.Printf("Sandbox %d, Iter %d: eA=%.4f, cQ=%.4f, aS=%.4f, Neurons=%d\n",
-			workerID, iterations, newExactAcc, newClosenessQuality, newApproxScore, neuronsAdded)
+func (r *growRun) emit(ctx context.Context, event GrowEvent) {
+	event.WorkerID = r.cfg.WorkerID
+	if r.cfg.Events == nil {
+		fmt.Println(event)
+		return
+	}
+	select {
+	case r.cfg.Events <- event:
+	case <-ctx.Done():
+	}
+}
 
-		newResult := ModelResult{
-			ExactAcc:      newExactAcc,
-			ClosenessBins: newClosenessBins,
-			ApproxScore:   newApproxScore,
+// done reports whether the iteration or failure budget is used up.
+func (r *growRun) done() bool {
+	return r.consecutiveFailures >= r.cfg.MaxConsecutiveFailures || r.iterations >= r.cfg.MaxIterations
+}
+
+// step grows one candidate from the best model and keeps it if it improves.
+func (r *growRun) step(ctx context.Context) {
+	cfg := r.cfg
+	r.iterations++
+	currentBP := r.best.Copy()
+	numToAdd := r.rng.Intn(cfg.MaxNeuronsToAdd-cfg.MinNeuronsToAdd+1) + cfg.MinNeuronsToAdd
+
+	added := 0
+	for i := 0; i < numToAdd; i++ {
+		mode := GrowWidth
+		if len(cfg.Modes) > 0 {
+			mode = cfg.Modes[r.rng.Intn(len(cfg.Modes))]
 		}
+		n := currentBP.grow(mode, cfg, r.scope, r.rng)
+		r.neuronsAdded += n
+		added += n
+	}
 
-		improvement := bp.ComputeTotalImprovement(newResult, bestExactAcc, bestClosenessQuality, bestApproxScore)
-		if improvement > 0 {
-			fmt.Printf("Sandbox %d: Improvement at Iter %d: Total Improvement=%.4f, eA=%.4f, cQ=%.4f, aS=%.4f, Neurons=%d\n",
-				workerID, iterations, improvement, newExactAcc, newClosenessQuality, newApproxScore, neuronsAdded)
-			bestBP = currentBP
-			bestExactAcc = newExactAcc
-			bestClosenessBins = newClosenessBins
-			bestClosenessQuality = newClosenessQuality
-			bestApproxScore = newApproxScore
-			consecutiveFailures = 0
-		} else {
-			consecutiveFailures++
+	newExactAcc, newClosenessBins, newApproxScore := r.evaluate(currentBP)
+	newClosenessQuality := r.policy.ClosenessQuality(newClosenessBins)
+
+	r.emit(ctx, GrowEvent{Kind: GrowEventIteration, Iteration: r.iterations,
+		ExactAcc: newExactAcc, Closeness: newClosenessQuality, ApproxScore: newApproxScore, NeuronsAdded: r.neuronsAdded})
+
+	newResult := ModelResult{
+		ExactAcc:      newExactAcc,
+		ClosenessBins: newClosenessBins,
+		ApproxScore:   newApproxScore,
+	}
+
+	improvement := r.policy.Improvement(newResult, r.bestExactAcc, r.bestClosenessQ, r.bestApproxScore)
+	newNovelty := 0.0
+	if r.novelty != nil {
+		behavior := r.novelty.behavior(currentBP)
+		newNovelty = r.novelty.score(behavior)
+		// Novelty of the incumbent is re-measured because the archive keeps growing.
+		r.bestNovelty = r.novelty.score(r.novelty.behavior(r.best))
+		improvement = r.novelty.blend(improvement, newNovelty-r.bestNovelty)
+		r.novelty.archive(behavior, newNovelty)
+	}
+
+	if improvement > 0 {
+		r.emit(ctx, GrowEvent{Kind: GrowEventImprovement, Iteration: r.iterations, Improvement: improvement,
+			ExactAcc: newExactAcc, Closeness: newClosenessQuality, ApproxScore: newApproxScore, NeuronsAdded: r.neuronsAdded})
+		currentBP.recordAcceptance("Grow", r.best,
+			map[string]interface{}{"worker": cfg.WorkerID, "iteration": r.iterations, "neurons_added": added},
+			map[string]float64{
+				"exact_acc":    newExactAcc - r.bestExactAcc,
+				"closeness":    newClosenessQuality - r.bestClosenessQ,
+				"approx_score": newApproxScore - r.bestApproxScore,
+				"improvement":  improvement,
+			})
+		parent := r.best
+		r.best = currentBP
+		r.bestExactAcc = newExactAcc
+		r.bestClosenessBins = newClosenessBins
+		r.bestClosenessQ = newClosenessQuality
+		r.bestApproxScore = newApproxScore
+		r.bestNovelty = newNovelty
+		r.consecutiveFailures = 0
+		if cfg.FineTune != nil {
+			r.fineTune(ctx, parent)
 		}
+	} else {
+		r.consecutiveFailures++
 	}
+}
+
+func (r *growRun) finish(ctx context.Context) {
+	r.emit(ctx, GrowEvent{Kind: GrowEventDone, Iteration: r.iterations, ConsecutiveFailures: r.consecutiveFailures,
+		ExactAcc: r.bestExactAcc, Closeness: r.bestClosenessQ, ApproxScore: r.bestApproxScore, NeuronsAdded: r.neuronsAdded})
+}
 
-	fmt.Printf("Sandbox %d: Exited after %d iterations, %d consecutive failures, eA=%.4f, cQ=%.4f, aS=%.4f\n",
-		workerID, iterations, consecutiveFailures, bestExactAcc, bestClosenessQuality, bestApproxScore)
+func (r *growRun) result() ModelResult {
 	return ModelResult{
-		BP:            bestBP,
-		ExactAcc:      bestExactAcc,
-		ClosenessBins: bestClosenessBins,
-		ApproxScore:   bestApproxScore,
-		NeuronsAdded:  neuronsAdded,
+		BP:            r.best,
+		ExactAcc:      r.bestExactAcc,
+		ClosenessBins: r.bestClosenessBins,
+		ApproxScore:   r.bestApproxScore,
+		NeuronsAdded:  r.neuronsAdded,
+		Novelty:       r.bestNovelty,
 	}
 }
 
@@ -302,7 +488,7 @@ func (bp *Phase) NEWGrow(minNeuronsToAdd int, maxNeuronsToAdd int, evalWithMulti
 	for consecutiveFailures < maxConsecutiveFailures && iterations < maxIterations {
 		iterations++
 		currentBP := bestBP.Copy()
-		numToAdd := rand.Intn(maxNeuronsToAdd-minNeuronsToAdd+1) + minNeuronsToAdd
+		numToAdd := bp.rng().Intn(maxNeuronsToAdd-minNeuronsToAdd+1) + minNeuronsToAdd
 
 		for i := 0; i < numToAdd; i++ {
 			newNeuron := currentBP.AddNeuronFromPreOutputs("dense", "", minConnections, maxConnections)
@@ -347,12 +533,19 @@ func (bp *Phase) NEWGrow(minNeuronsToAdd int, maxNeuronsToAdd int, evalWithMulti
 
 // OptimizeNewNeuronParameters optimizes the parameters of a newly added neuron using a perturbation-based search.
 func (bp *Phase) OptimizeNewNeuronParameters(newNeuronID int, checkpoints []map[int]map[string]interface{}, labels []float64, numPerturbations int, sigma float64, maxIterations int) {
+	bp.OptimizeNewNeuronParametersWithPolicy(nil, newNeuronID, checkpoints, labels, numPerturbations, sigma, maxIterations)
+}
+
+// OptimizeNewNeuronParametersWithPolicy is OptimizeNewNeuronParameters comparing the
+// candidates with policy; nil uses bp's ImprovementPolicy.
+func (bp *Phase) OptimizeNewNeuronParametersWithPolicy(policy ImprovementPolicy, newNeuronID int, checkpoints []map[int]map[string]interface{}, labels []float64, numPerturbations int, sigma float64, maxIterations int) {
+	policy = bp.resolvePolicy(policy)
 	// Get initial parameters
 	currentParams := bp.GetNewNeuronParameters(newNeuronID)
 
 	// Compute initial metrics
 	currentExactAcc, currentClosenessBins, currentApproxScore := bp.EvaluateMetricsFromCheckpoints(checkpoints, labels)
-	currentClosenessQuality := bp.ComputeClosenessQuality(currentClosenessBins)
+	currentClosenessQuality := policy.ClosenessQuality(currentClosenessBins)
 
 	// Log initial metrics
 	//fmt.Printf("Starting optimization for neuron %d. Initial Metrics: ExactAcc=%.4f, ClosenessQuality=%.4f, ApproxScore=%.4f\n",
@@ -368,7 +561,7 @@ func (bp *Phase) OptimizeNewNeuronParameters(newNeuronID int, checkpoints []map[
 			// Generate perturbation
 			delta := make([]float64, len(currentParams))
 			for j := range delta {
-				delta[j] = rand.NormFloat64() * sigma
+				delta[j] = bp.rng().NormFloat64() * sigma
 			}
 			perturbedParams := make([]float64, len(currentParams))
 			for j := range perturbedParams {
@@ -381,7 +574,7 @@ func (bp *Phase) OptimizeNewNeuronParameters(newNeuronID int, checkpoints []map[
 			// := bp.ComputeClosenessQuality(newClosenessBins)
 
 			// Compute total improvement
-			improvement := bp.ComputeTotalImprovement(ModelResult{
+			improvement := policy.Improvement(ModelResult{
 				ExactAcc:      newExactAcc,
 				ClosenessBins: newClosenessBins,
 				ApproxScore:   newApproxScore,
@@ -404,7 +597,7 @@ func (bp *Phase) OptimizeNewNeuronParameters(newNeuronID int, checkpoints []map[
 
 			// Update current metrics
 			currentExactAcc, currentClosenessBins, currentApproxScore = bp.EvaluateMetricsFromCheckpoints(checkpoints, labels)
-			currentClosenessQuality = bp.ComputeClosenessQuality(currentClosenessBins)
+			currentClosenessQuality = policy.ClosenessQuality(currentClosenessBins)
 
 			// Log improvement
 			fmt.Printf("Neuron %d, Iteration %d: Improved total improvement to %.4f\n", newNeuronID, iter, bestImprovement)
@@ -423,12 +616,19 @@ func (bp *Phase) OptimizeNewNeuronParameters(newNeuronID int, checkpoints []map[
 // AdaptiveOptimizeNewNeuronParameters performs perturbation-based optimization on a new neuron,
 // adapting the sigma value based on the improvement measured by ComputeTotalImprovement.
 func (bp *Phase) AdaptiveOptimizeNewNeuronParameters(newNeuronID int, checkpoints []map[int]map[string]interface{}, labels []float64, numPerturbations int, initialSigma float64, maxIterations int, improvementThreshold float64) {
+	bp.AdaptiveOptimizeNewNeuronParametersWithPolicy(nil, newNeuronID, checkpoints, labels, numPerturbations, initialSigma, maxIterations, improvementThreshold)
+}
+
+// AdaptiveOptimizeNewNeuronParametersWithPolicy is AdaptiveOptimizeNewNeuronParameters comparing the
+// candidates with policy; nil uses bp's ImprovementPolicy.
+func (bp *Phase) AdaptiveOptimizeNewNeuronParametersWithPolicy(policy ImprovementPolicy, newNeuronID int, checkpoints []map[int]map[string]interface{}, labels []float64, numPerturbations int, initialSigma float64, maxIterations int, improvementThreshold float64) {
+	policy = bp.resolvePolicy(policy)
 	// Retrieve the initial parameters of the neuron.
 	currentParams := bp.GetNewNeuronParameters(newNeuronID)
 
 	// Evaluate initial network metrics.
 	currentExactAcc, currentClosenessBins, currentApproxScore := bp.EvaluateMetricsFromCheckpoints(checkpoints, labels)
-	currentClosenessQuality := bp.ComputeClosenessQuality(currentClosenessBins)
+	currentClosenessQuality := policy.ClosenessQuality(currentClosenessBins)
 
 	// Set sigma to the initial value.
 	currentSigma := initialSigma
@@ -443,7 +643,7 @@ func (bp *Phase) AdaptiveOptimizeNewNeuronParameters(newNeuronID int, checkpoint
 			// Generate a delta vector with each element drawn from N(0, currentSigma).
 			delta := make([]float64, len(currentParams))
 			for j := range delta {
-				delta[j] = rand.NormFloat64() * currentSigma
+				delta[j] = bp.rng().NormFloat64() * currentSigma
 			}
 			// Create perturbed parameters.
 			perturbedParams := make([]float64, len(currentParams))
@@ -459,7 +659,7 @@ func (bp *Phase) AdaptiveOptimizeNewNeuronParameters(newNeuronID int, checkpoint
 			//newClosenessQuality := bp.ComputeClosenessQuality(newClosenessBins)
 
 			// Compute improvement based on the improvement metric.
-			improvement := bp.ComputeTotalImprovement(ModelResult{
+			improvement := policy.Improvement(ModelResult{
 				ExactAcc:      newExactAcc,
 				ClosenessBins: newClosenessBins,
 				ApproxScore:   newApproxScore,
@@ -477,7 +677,7 @@ func (bp *Phase) AdaptiveOptimizeNewNeuronParameters(newNeuronID int, checkpoint
 			currentParams = bestParams
 			bp.SetNewNeuronParameters(newNeuronID, bestParams)
 			currentExactAcc, currentClosenessBins, currentApproxScore = bp.EvaluateMetricsFromCheckpoints(checkpoints, labels)
-			currentClosenessQuality = bp.ComputeClosenessQuality(currentClosenessBins)
+			currentClosenessQuality = policy.ClosenessQuality(currentClosenessBins)
 
 			// If the improvement is strong enough, reduce sigma for fine-tuning;
 			// otherwise, increase sigma to explore more.
@@ -508,12 +708,19 @@ func vectorNorm(vec []float64) float64 {
 // It aggregates the perturbation deltas using their improvement (as computed by ComputeTotalImprovement)
 // and then updates the neuron's parameters accordingly. It also adapts sigma based on the norm of the update.
 func (bp *Phase) AdaptiveOptimizeNewNeuronParametersV2(newNeuronID int, checkpoints []map[int]map[string]interface{}, labels []float64, numPerturbations int, initialSigma float64, maxIterations int, alpha float64) {
+	bp.AdaptiveOptimizeNewNeuronParametersV2WithPolicy(nil, newNeuronID, checkpoints, labels, numPerturbations, initialSigma, maxIterations, alpha)
+}
+
+// AdaptiveOptimizeNewNeuronParametersV2WithPolicy is AdaptiveOptimizeNewNeuronParametersV2 comparing the
+// candidates with policy; nil uses bp's ImprovementPolicy.
+func (bp *Phase) AdaptiveOptimizeNewNeuronParametersV2WithPolicy(policy ImprovementPolicy, newNeuronID int, checkpoints []map[int]map[string]interface{}, labels []float64, numPerturbations int, initialSigma float64, maxIterations int, alpha float64) {
+	policy = bp.resolvePolicy(policy)
 	// Get the current parameters of the neuron.
 	currentParams := bp.GetNewNeuronParameters(newNeuronID)
 	
 	// Evaluate baseline metrics.
 	currentExactAcc, currentClosenessBins, currentApproxScore := bp.EvaluateMetricsFromCheckpoints(checkpoints, labels)
-	currentClosenessQuality := bp.ComputeClosenessQuality(currentClosenessBins)
+	currentClosenessQuality := policy.ClosenessQuality(currentClosenessBins)
 	
 	// Start with the given sigma.
 	currentSigma := initialSigma
@@ -528,7 +735,7 @@ func (bp *Phase) AdaptiveOptimizeNewNeuronParametersV2(newNeuronID int, checkpoi
 			// Generate a random perturbation delta vector.
 			delta := make([]float64, len(currentParams))
 			for j := range delta {
-				delta[j] = rand.NormFloat64() * currentSigma
+				delta[j] = bp.rng().NormFloat64() * currentSigma
 			}
 			
 			// Compute candidate parameters: current + delta.
@@ -552,7 +759,7 @@ func (bp *Phase) AdaptiveOptimizeNewNeuronParametersV2(newNeuronID int, checkpoi
 			}
 			
 			// Compute the improvement over the baseline.
-			improvement := bp.ComputeTotalImprovement(candidateResult, currentExactAcc, currentClosenessQuality, currentApproxScore)
+			improvement := policy.Improvement(candidateResult, currentExactAcc, currentClosenessQuality, currentApproxScore)
 			if improvement < 0 {
 				improvement = 0 // Only consider positive improvements.
 			}
@@ -580,8 +787,8 @@ func (bp *Phase) AdaptiveOptimizeNewNeuronParametersV2(newNeuronID int, checkpoi
 			
 			// Re-evaluate metrics with the updated parameters.
 			newExactAcc, newClosenessBins, newApproxScore := bp.EvaluateMetricsFromCheckpoints(checkpoints, labels)
-			newClosenessQuality := bp.ComputeClosenessQuality(newClosenessBins)
-			overallImprovement := bp.ComputeTotalImprovement(ModelResult{
+			newClosenessQuality := policy.ClosenessQuality(newClosenessBins)
+			overallImprovement := policy.Improvement(ModelResult{
 				ExactAcc:      newExactAcc,
 				ClosenessBins: newClosenessBins,
 				ApproxScore:   newApproxScore,
@@ -611,13 +818,14 @@ func (bp *Phase) AdaptiveOptimizeNewNeuronParametersV2(newNeuronID int, checkpoi
 }
 
 
-// GetNewNeuronParameters retrieves the parameters (incoming weights, bias, outgoing weights) of a neuron.
+// GetNewNeuronParameters retrieves the parameters (incoming weights, bias, outgoing weights) of a neuron,
+// followed by its type-specific parameters (see typeParameters).
 func (bp *Phase) GetNewNeuronParameters(newNeuronID int) []float64 {
 	newNeuron := bp.Neurons[newNeuronID]
 	params := []float64{}
 	// Incoming weights
 	for _, conn := range newNeuron.Connections {
-		params = append(params, conn[1])
+		params = append(params, conn.Weight)
 	}
 	// Bias
 	params = append(params, newNeuron.Bias)
@@ -625,12 +833,16 @@ func (bp *Phase) GetNewNeuronParameters(newNeuronID int) []float64 {
 	for _, outID := range bp.OutputNodes {
 		outNeuron := bp.Neurons[outID]
 		for _, conn := range outNeuron.Connections {
-			if int(conn[0]) == newNeuronID {
-				params = append(params, conn[1])
+			if conn.Source == newNeuronID {
+				params = append(params, conn.Weight)
 				break
 			}
 		}
 	}
+	// Type-specific parameters
+	for _, p := range typeParameters(newNeuron) {
+		params = append(params, *p)
+	}
 	return params
 }
 
@@ -640,7 +852,7 @@ func (bp *Phase) SetNewNeuronParameters(newNeuronID int, params []float64) {
 	idx := 0
 	// Set incoming weights
 	for i := range newNeuron.Connections {
-		newNeuron.Connections[i][1] = params[idx]
+		newNeuron.Connections[i].Weight = params[idx]
 		idx++
 	}
 	// Set bias
@@ -650,13 +862,48 @@ func (bp *Phase) SetNewNeuronParameters(newNeuronID int, params []float64) {
 	for _, outID := range bp.OutputNodes {
 		outNeuron := bp.Neurons[outID]
 		for i, conn := range outNeuron.Connections {
-			if int(conn[0]) == newNeuronID {
-				outNeuron.Connections[i][1] = params[idx]
+			if conn.Source == newNeuronID {
+				outNeuron.Connections[i].Weight = params[idx]
 				idx++
 				break
 			}
 		}
 	}
+	// Set type-specific parameters
+	for _, p := range typeParameters(newNeuron) {
+		if idx >= len(params) {
+			break
+		}
+		*p = params[idx]
+		idx++
+	}
+}
+
+// typeParameters returns pointers to the trainable parameters a neuron's type adds, in a fixed
+// order: the LSTM gate weights (input, forget, output, cell), the CNN kernels one after another,
+// or the BatchNorm gamma and beta. Other types have none.
+func typeParameters(neuron *Neuron) []*float64 {
+	params := []*float64{}
+	switch neuron.Type {
+	case "lstm":
+		for _, gate := range []string{"input", "forget", "output", "cell"} {
+			weights := neuron.GateWeights[gate]
+			for i := range weights {
+				params = append(params, &weights[i])
+			}
+		}
+	case "cnn":
+		for _, kernel := range neuron.Kernels {
+			for i := range kernel {
+				params = append(params, &kernel[i])
+			}
+		}
+	case "batch_norm":
+		if neuron.BatchNormParams != nil {
+			params = append(params, &neuron.BatchNormParams.Gamma, &neuron.BatchNormParams.Beta)
+		}
+	}
+	return params
 }
 
 // EvaluateExactAccuracy computes the exact accuracy using checkpoints.
@@ -136,6 +136,9 @@ func (bp *Phase) LoadNeurons(jsonData string) error {
 		}
 	}
 
+	if bp.Strict {
+		return bp.ValidateStrict()
+	}
 	return nil
 }
 
@@ -226,8 +229,15 @@ func (bp *Phase) SerializeToJSON() (string, error) {
 }
 
 // FromJSON deserializes the Phase from a JSON string.
+// In strict mode the result is validated and a *ValidationError is returned for malformed networks.
 func (bp *Phase) DeserializesFromJSON(data string) error {
-	return json.Unmarshal([]byte(data), bp)
+	if err := json.Unmarshal([]byte(data), bp); err != nil {
+		return err
+	}
+	if bp.Strict {
+		return bp.ValidateStrict()
+	}
+	return nil
 }
 
 // getAllNeuronIDs retrieves the IDs of all neurons in the Phase.
@@ -241,14 +251,14 @@ func (bp *Phase) getAllNeuronIDs() []int {
 
 // getRandomConnectionPair selects a random valid source and target neuron IDs for adding a connection.
 // Returns -1, -1 if no valid pair is found.
-func (bp *Phase) getRandomConnectionPair() (int, int) {
-	neuronIDs := bp.getAllNeuronIDs()
+func (bp *Phase) getRandomConnectionPair(rng *rand.Rand) (int, int) {
+	neuronIDs := bp.sortedNeuronIDs() // Sorted, so a seeded rng picks the same pair
 	if len(neuronIDs) < 2 {
 		return -1, -1
 	}
 
 	// Shuffle neuron IDs to randomize selection
-	rand.Shuffle(len(neuronIDs), func(i, j int) { neuronIDs[i], neuronIDs[j] = neuronIDs[j], neuronIDs[i] })
+	rng.Shuffle(len(neuronIDs), func(i, j int) { neuronIDs[i], neuronIDs[j] = neuronIDs[j], neuronIDs[i] })
 
 	for _, source := range neuronIDs {
 		for _, target := range neuronIDs {
@@ -271,21 +281,10 @@ func getMaxFloat() float64 {
 	return math.MaxFloat64
 }
 
+// ValidateConnections reports whether every output neuron can be reached from the inputs.
+// See Validate for a full structural check.
 func (bp *Phase) ValidateConnections() bool {
-	visited := map[int]bool{}
-	var dfs func(int)
-	dfs = func(id int) {
-		if visited[id] {
-			return
-		}
-		visited[id] = true
-		for _, conn := range bp.Neurons[id].Connections {
-			dfs(int(conn[0]))
-		}
-	}
-	for _, inputID := range bp.InputNodes {
-		dfs(inputID)
-	}
+	visited := bp.forwardReachable()
 	for _, outputID := range bp.OutputNodes {
 		if !visited[outputID] {
 			fmt.Printf("Output Neuron %d is not connected.\n", outputID)
@@ -301,7 +300,7 @@ func (bp *Phase) connectionExists(sourceID, targetID int) bool {
 		return false
 	}
 	for _, conn := range targetNeuron.Connections {
-		if int(conn[0]) == sourceID {
+		if conn.Source == sourceID {
 			return true
 		}
 	}
@@ -330,22 +329,18 @@ func (p *Phase) MarshalJSON() ([]byte, error) {
 	}
 
 	for id, neuron := range p.Neurons {
-		newNeuron := &Neuron{
-			ID:          neuron.ID,
-			Type:        neuron.Type,
-			Value:       replaceNaN(neuron.Value),
-			Bias:        replaceNaN(neuron.Bias),
-			Activation:  neuron.Activation,
-			CellState:   replaceNaN(neuron.CellState),
-			GateWeights: neuron.GateWeights,
-		}
+		newNeuron := *neuron
+		newNeuron.Value = replaceNaN(neuron.Value)
+		newNeuron.Bias = replaceNaN(neuron.Bias)
+		newNeuron.CellState = replaceNaN(neuron.CellState)
 
-		newNeuron.Connections = make([][]float64, len(neuron.Connections))
+		newNeuron.Connections = make([]Connection, len(neuron.Connections))
 		for i, conn := range neuron.Connections {
-			newNeuron.Connections[i] = []float64{conn[0], replaceNaN(conn[1])}
+			conn.Weight = replaceNaN(conn.Weight)
+			newNeuron.Connections[i] = conn
 		}
 
-		aux.Neurons[id] = newNeuron
+		aux.Neurons[id] = &newNeuron
 	}
 
 	return json.Marshal(aux)
@@ -373,11 +368,11 @@ func (bp *Phase) GetOutputIds() []int {
 	return bp.OutputNodes
 }
 
-// getNonInputNeuronIDs returns a slice of IDs for all non-input neurons in the Phase.
+// getNonInputNeuronIDs returns the IDs of all non-input neurons in the Phase, ascending.
 func (bp *Phase) getNonInputNeuronIDs() []int {
 	var ids []int
-	for id, neuron := range bp.Neurons {
-		if neuron.Type != "input" {
+	for _, id := range bp.sortedNeuronIDs() {
+		if bp.Neurons[id].Type != "input" {
 			ids = append(ids, id)
 		}
 	}
@@ -0,0 +1,427 @@
+package phase
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Severity classifies a validation issue.
+type Severity int
+
+const (
+	SeverityWarning Severity = iota // The network runs, but probably not as intended
+	SeverityError                   // The network is malformed
+)
+
+// String returns the lowercase name of the severity.
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Issue codes reported by Validate.
+const (
+	IssueDanglingSource      = "dangling_source"       // Connection from a neuron that does not exist
+	IssueDuplicateConnection = "duplicate_connection"  // Two connections from the same source
+	IssueUnknownType         = "unknown_type"          // Neuron type not handled by ProcessNeuron
+	IssueUnknownActivation   = "unknown_activation"    // Activation not in the activation map
+	IssueGateMismatch        = "gate_mismatch"         // LSTM gate weights do not match the connection count
+	IssueUnreachableOutput   = "unreachable_output"    // No path from any input to an output neuron
+	IssueDeadNeuron          = "dead_neuron"           // Neuron that cannot influence any output
+	IssueCycle               = "cycle"                 // Cycle without an rnn or lstm neuron in it
+	IssueNaNParameter        = "nan_parameter"         // NaN or Inf bias, weight, gate, kernel or batch norm value
+	IssueIDCollision         = "id_collision"          // ID used by both Neurons and QuantumNeurons
+	IssueIDMismatch          = "id_mismatch"           // Map key differs from the neuron's ID field
+	IssueMissingNode         = "missing_node"          // InputNodes or OutputNodes entry without a neuron
+	IssueInputHasConnections = "input_has_connections" // Input neuron with incoming connections, which are ignored
+)
+
+// Issue is a single problem found by Validate.
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	NeuronID int      `json:"neuron_id"`
+	Message  string   `json:"message"`
+}
+
+// String formats the issue as "severity [code] neuron N: message".
+func (i Issue) String() string {
+	return fmt.Sprintf("%s [%s] neuron %d: %s", i.Severity, i.Code, i.NeuronID, i.Message)
+}
+
+// ValidationError is returned by strict loaders when a Phase has error-level issues.
+type ValidationError struct {
+	Issues []Issue
+}
+
+// Error lists the error-level issues.
+func (e *ValidationError) Error() string {
+	msgs := []string{}
+	for _, issue := range e.Issues {
+		if issue.Severity == SeverityError {
+			msgs = append(msgs, issue.String())
+		}
+	}
+	return fmt.Sprintf("invalid phase: %d error(s): %s", len(msgs), strings.Join(msgs, "; "))
+}
+
+// HasErrors reports whether any issue has error severity.
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// knownNeuronTypes lists the neuron types handled by ProcessNeuron.
+var knownNeuronTypes = map[string]bool{
+	"input": true, "dense": true, "rnn": true, "lstm": true, "cnn": true,
+	"batch_norm": true, "dropout": true, "attention": true, "nca": true,
+}
+
+// lstmGates lists the gate weight vectors every LSTM neuron needs.
+var lstmGates = []string{"input", "forget", "output", "cell"}
+
+// Validate checks the Phase for structural problems and returns them sorted by neuron ID.
+// Errors mark a malformed network; warnings mark parts that run but do nothing useful.
+// Validate never modifies the Phase.
+func (bp *Phase) Validate() []Issue {
+	issues := []Issue{}
+	report := func(sev Severity, code string, id int, format string, args ...interface{}) {
+		issues = append(issues, Issue{Severity: sev, Code: code, NeuronID: id, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, id := range bp.sortedNeuronIDs() {
+		neuron := bp.Neurons[id]
+		if neuron == nil {
+			report(SeverityError, IssueMissingNode, id, "nil neuron in map")
+			continue
+		}
+		if neuron.ID != id {
+			report(SeverityError, IssueIDMismatch, id, "stored under key %d but has ID %d", id, neuron.ID)
+		}
+		if _, exists := bp.QuantumNeurons[id]; exists {
+			report(SeverityError, IssueIDCollision, id, "ID is also used by a quantum neuron")
+		}
+		if !knownNeuronTypes[neuron.Type] {
+			report(SeverityError, IssueUnknownType, id, "unknown neuron type %q", neuron.Type)
+		}
+		if neuron.Type != "input" && !bp.isKnownActivation(neuron.Activation) {
+			report(SeverityError, IssueUnknownActivation, id, "unknown activation %q", neuron.Activation)
+		}
+		if neuron.Type == "input" && len(neuron.Connections) > 0 {
+			report(SeverityWarning, IssueInputHasConnections, id, "input neuron has %d incoming connections that are ignored", len(neuron.Connections))
+		}
+
+		seen := make(map[int]bool, len(neuron.Connections))
+		for _, conn := range neuron.Connections {
+			_, isNeuron := bp.Neurons[conn.Source]
+			_, isQuantum := bp.QuantumNeurons[conn.Source]
+			if !isNeuron && !isQuantum {
+				report(SeverityError, IssueDanglingSource, id, "connection from missing neuron %d", conn.Source)
+			}
+			if seen[conn.Source] {
+				report(SeverityError, IssueDuplicateConnection, id, "more than one connection from neuron %d", conn.Source)
+			}
+			seen[conn.Source] = true
+		}
+
+		if neuron.Type == "lstm" {
+			for _, gate := range lstmGates {
+				if n := len(neuron.GateWeights[gate]); n != len(neuron.Connections) {
+					report(SeverityError, IssueGateMismatch, id, "%s gate has %d weights for %d connections", gate, n, len(neuron.Connections))
+				}
+			}
+		}
+
+		if bad := nonFiniteParameters(neuron); len(bad) > 0 {
+			report(SeverityError, IssueNaNParameter, id, "non-finite %s", strings.Join(bad, ", "))
+		}
+	}
+
+	for _, id := range bp.InputNodes {
+		if _, exists := bp.Neurons[id]; !exists {
+			report(SeverityError, IssueMissingNode, id, "listed in InputNodes but does not exist")
+		}
+	}
+	for _, id := range bp.OutputNodes {
+		if _, exists := bp.Neurons[id]; !exists {
+			report(SeverityError, IssueMissingNode, id, "listed in OutputNodes but does not exist")
+		}
+	}
+
+	forward := bp.forwardReachable()
+	for _, id := range bp.OutputNodes {
+		if _, exists := bp.Neurons[id]; exists && !forward[id] {
+			report(SeverityError, IssueUnreachableOutput, id, "output cannot be reached from any input")
+		}
+	}
+
+	if len(bp.OutputNodes) > 0 {
+		backward := bp.backwardReachable()
+		for _, id := range bp.sortedNeuronIDs() {
+			if neuron := bp.Neurons[id]; neuron != nil && neuron.Type != "input" && !backward[id] {
+				report(SeverityWarning, IssueDeadNeuron, id, "neuron does not feed any output")
+			}
+		}
+	}
+
+	for _, scc := range bp.stronglyConnectedComponents() {
+		recurrent := false
+		for _, id := range scc {
+			if t := bp.Neurons[id].Type; t == "rnn" || t == "lstm" {
+				recurrent = true
+				break
+			}
+		}
+		if !recurrent {
+			report(SeverityError, IssueCycle, scc[0], "cycle through non-recurrent neurons %v", scc)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].NeuronID < issues[j].NeuronID
+	})
+	return issues
+}
+
+// ValidateStrict runs Validate and returns a *ValidationError if any error-level issue was found.
+func (bp *Phase) ValidateStrict() error {
+	issues := bp.Validate()
+	if HasErrors(issues) {
+		return &ValidationError{Issues: issues}
+	}
+	return nil
+}
+
+// isKnownActivation reports whether the activation can be applied. An empty activation
+// falls back to linear, and softmax is handled across the output layer.
+func (bp *Phase) isKnownActivation(activation string) bool {
+	if activation == "" || activation == "softmax" {
+		return true
+	}
+	if _, exists := scalarActivationFunctions[activation]; exists {
+		return true
+	}
+	_, exists := bp.ScalarActivationMap[activation]
+	return exists
+}
+
+// nonFiniteParameters lists the trainable parameters of a neuron that are NaN or Inf.
+func nonFiniteParameters(neuron *Neuron) []string {
+	bad := []string{}
+	finite := func(v float64) bool { return !math.IsNaN(v) && !math.IsInf(v, 0) }
+
+	if !finite(neuron.Bias) {
+		bad = append(bad, "bias")
+	}
+	for _, conn := range neuron.Connections {
+		if !finite(conn.Weight) {
+			bad = append(bad, fmt.Sprintf("weight from %d", conn.Source))
+		}
+	}
+	for _, gate := range lstmGates {
+		for _, w := range neuron.GateWeights[gate] {
+			if !finite(w) {
+				bad = append(bad, gate+" gate weight")
+				break
+			}
+		}
+	}
+	for k, kernel := range neuron.Kernels {
+		for _, w := range kernel {
+			if !finite(w) {
+				bad = append(bad, fmt.Sprintf("kernel %d", k))
+				break
+			}
+		}
+	}
+	if p := neuron.BatchNormParams; p != nil && !(finite(p.Gamma) && finite(p.Beta) && finite(p.Mean) && finite(p.Var)) {
+		bad = append(bad, "batch norm params")
+	}
+	return bad
+}
+
+// forwardReachable returns the neurons reachable from the inputs along enabled connections.
+// Neurons of type "input" count as inputs when InputNodes is empty.
+func (bp *Phase) forwardReachable() map[int]bool {
+	downstream := make(map[int][]int)
+	for id, neuron := range bp.Neurons {
+		if neuron == nil {
+			continue
+		}
+		for _, conn := range neuron.Connections {
+			if conn.Enabled {
+				downstream[conn.Source] = append(downstream[conn.Source], id)
+			}
+		}
+	}
+
+	starts := bp.InputNodes
+	if len(starts) == 0 {
+		for id, neuron := range bp.Neurons {
+			if neuron != nil && neuron.Type == "input" {
+				starts = append(starts, id)
+			}
+		}
+	}
+
+	visited := map[int]bool{}
+	stack := append([]int{}, starts...)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		stack = append(stack, downstream[id]...)
+	}
+	return visited
+}
+
+// backwardReachable returns the neurons that feed an output along enabled connections.
+func (bp *Phase) backwardReachable() map[int]bool {
+	visited := map[int]bool{}
+	stack := append([]int{}, bp.OutputNodes...)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		if neuron := bp.Neurons[id]; neuron != nil {
+			for _, conn := range neuron.Connections {
+				if conn.Enabled {
+					stack = append(stack, conn.Source)
+				}
+			}
+		}
+	}
+	return visited
+}
+
+// stronglyConnectedComponents returns every cycle in the enabled connection graph as a
+// sorted list of neuron IDs (Tarjan's algorithm). Self-loops count as cycles of one.
+func (bp *Phase) stronglyConnectedComponents() [][]int {
+	index := 0
+	indices := map[int]int{}
+	lowlink := map[int]int{}
+	onStack := map[int]bool{}
+	stack := []int{}
+	components := [][]int{}
+
+	var strongConnect func(id int)
+	strongConnect = func(id int) {
+		indices[id] = index
+		lowlink[id] = index
+		index++
+		stack = append(stack, id)
+		onStack[id] = true
+
+		selfLoop := false
+		for _, conn := range bp.Neurons[id].Connections {
+			src := conn.Source
+			if !conn.Enabled || bp.Neurons[src] == nil {
+				continue
+			}
+			if src == id {
+				selfLoop = true
+			}
+			if _, visited := indices[src]; !visited {
+				strongConnect(src)
+				lowlink[id] = min(lowlink[id], lowlink[src])
+			} else if onStack[src] {
+				lowlink[id] = min(lowlink[id], indices[src])
+			}
+		}
+
+		if lowlink[id] == indices[id] {
+			component := []int{}
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == id {
+					break
+				}
+			}
+			if len(component) > 1 || selfLoop {
+				sort.Ints(component)
+				components = append(components, component)
+			}
+		}
+	}
+
+	for _, id := range bp.sortedNeuronIDs() {
+		if bp.Neurons[id] == nil {
+			continue
+		}
+		if _, visited := indices[id]; !visited {
+			strongConnect(id)
+		}
+	}
+	return components
+}
+
+// strictCheckpoint snapshots the neurons before a mutation when Strict is set. The returned
+// function validates the result, rolls the mutation back if it introduced new errors, and
+// reports whether it did. Mutations use it through mutationCheckpoint.
+func (bp *Phase) strictCheckpoint(operator string) func() bool {
+	if !bp.Strict {
+		return func() bool { return false }
+	}
+	before := issueKeys(bp.Validate())
+	snapshot := make(map[int]*Neuron, len(bp.Neurons))
+	for id, neuron := range bp.Neurons {
+		snapshot[id] = deepCopyNeuron(neuron)
+	}
+	inputs := append([]int{}, bp.InputNodes...)
+	outputs := append([]int{}, bp.OutputNodes...)
+	trainable := append([]int(nil), bp.TrainableNeurons...)
+
+	return func() bool {
+		introduced := []Issue{}
+		for _, issue := range bp.Validate() {
+			if issue.Severity == SeverityError && !before[issueKey(issue)] {
+				introduced = append(introduced, issue)
+			}
+		}
+		if len(introduced) == 0 {
+			return false
+		}
+		bp.Neurons = snapshot
+		bp.InputNodes = inputs
+		bp.OutputNodes = outputs
+		bp.TrainableNeurons = trainable
+		bp.reverts++
+		if bp.Debug {
+			fmt.Printf("Strict mode: reverted %s: %v\n", operator, &ValidationError{Issues: introduced})
+		}
+		return true
+	}
+}
+
+// issueKey identifies an issue by code, neuron and message.
+func issueKey(issue Issue) string {
+	return fmt.Sprintf("%s/%d/%s", issue.Code, issue.NeuronID, issue.Message)
+}
+
+// issueKeys returns the keys of the error-level issues.
+func issueKeys(issues []Issue) map[string]bool {
+	keys := make(map[string]bool, len(issues))
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			keys[issueKey(issue)] = true
+		}
+	}
+	return keys
+}